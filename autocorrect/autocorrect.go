@@ -0,0 +1,318 @@
+package autocorrect
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nvlled/gosn30/dict"
+)
+
+// Costs used by the weighted edit distance. Substituting a letter with
+// one bound to a neighbouring input is cheaper than an arbitrary
+// substitution, since that's the usual misfire on a gamepad.
+const (
+	CostNeighbour  = 0.4
+	CostSubstitute = 1.0
+	CostInsert     = 1.0
+	CostDelete     = 1.0
+	CostTranspose  = 0.7
+)
+
+// Separators maps the keysyms that end a word to the text they produce.
+var Separators = map[string]string{
+	"space":  " ",
+	"Return": "\n",
+	"period": ".",
+	"comma":  ",",
+	"colon":  ":",
+}
+
+// Adjacency lists, for each letter, the letters bound to physically
+// adjacent inputs.
+type Adjacency map[rune]string
+
+func (adj Adjacency) Link(a, b rune) {
+	if !strings.ContainsRune(adj[a], b) {
+		adj[a] += string(b)
+	}
+	if !strings.ContainsRune(adj[b], a) {
+		adj[b] += string(a)
+	}
+}
+
+// LinkRing links each key to the keys next to it, wrapping around.
+// Keys are given in clockwise order around a button cluster.
+func (adj Adjacency) LinkRing(keys ...string) {
+	for i := range keys {
+		a, b := keys[i], keys[(i+1)%len(keys)]
+		if len(a) == 1 && len(b) == 1 {
+			adj.Link(rune(a[0]), rune(b[0]))
+		}
+	}
+}
+
+func (adj Adjacency) IsNeighbour(a, b rune) bool {
+	return strings.ContainsRune(adj[a], b)
+}
+
+// Edit describes how to rewrite text that was already typed: erase
+// Erase characters before the cursor, then type Text.
+type Edit struct {
+	Erase int
+	Text  string
+}
+
+type Correction struct {
+	Original    string
+	Replacement string
+	Separator   string
+	Applied     bool
+}
+
+type Corrector struct {
+	Dict      *dict.Dict
+	Adjacency Adjacency
+
+	// AutoApply applies corrections as soon as a word ends. Otherwise the
+	// correction is only offered and applied with Toggle.
+	AutoApply bool
+	// MinLength is the shortest word that gets corrected, so short
+	// commands and abbreviations are left alone.
+	MinLength int
+	// CostPerLetter is the edit distance allowed for each letter of the
+	// word, up to MaxCost, so short words need a closer match.
+	CostPerLetter float64
+	MaxCost       float64
+
+	word []rune
+	last *Correction
+}
+
+func New(d *dict.Dict, adj Adjacency) *Corrector {
+	return &Corrector{
+		Dict:          d,
+		Adjacency:     adj,
+		MinLength:     3,
+		CostPerLetter: 0.3,
+		MaxCost:       1.5,
+	}
+}
+
+func (c *Corrector) Word() string {
+	return string(c.word)
+}
+
+func (c *Corrector) Last() *Correction {
+	return c.last
+}
+
+func (c *Corrector) Reset() {
+	c.word = c.word[:0]
+	c.last = nil
+}
+
+// KeyPress updates the word buffer for a keysym that is about to be
+// sent. When the key ends a word and a correction is applied, the
+// returned edit must be performed before sending the key.
+func (c *Corrector) KeyPress(key string, upper bool) (Edit, bool) {
+	if sep, ok := Separators[key]; ok {
+		return c.EndWord(sep)
+	}
+	c.last = nil
+	if key == "BackSpace" {
+		if len(c.word) > 0 {
+			c.word = c.word[:len(c.word)-1]
+		}
+		return Edit{}, false
+	}
+	if r, size := utf8.DecodeRuneInString(key); size == len(key) && unicode.IsPrint(r) {
+		if upper {
+			r = unicode.ToUpper(r)
+		}
+		c.word = append(c.word, r)
+		return Edit{}, false
+	}
+	// Anything else (arrows, Delete, shortcuts) may move the cursor,
+	// so the buffer can no longer be trusted.
+	c.Reset()
+	return Edit{}, false
+}
+
+// Text updates the word buffer for text entered directly.
+func (c *Corrector) Text(text string) {
+	c.last = nil
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			c.word = append(c.word, r)
+		} else {
+			c.word = c.word[:0]
+		}
+	}
+}
+
+// EndWord checks the current word against the dictionary. sep is the
+// text that ends the word, which is not yet typed.
+func (c *Corrector) EndWord(sep string) (Edit, bool) {
+	word := string(c.word)
+	c.word = c.word[:0]
+	c.last = nil
+
+	repl, ok := c.Suggest(word)
+	if !ok {
+		return Edit{}, false
+	}
+	c.last = &Correction{
+		Original:    word,
+		Replacement: repl,
+		Separator:   sep,
+	}
+	if !c.AutoApply {
+		return Edit{}, false
+	}
+	c.last.Applied = true
+	return Edit{Erase: utf8.RuneCountInString(word), Text: repl}, true
+}
+
+// Toggle applies an offered correction, or reverts an applied one. It
+// only works right after the word ended.
+func (c *Corrector) Toggle() (Edit, bool) {
+	last := c.last
+	if last == nil {
+		return Edit{}, false
+	}
+	from, to := last.Original, last.Replacement
+	if last.Applied {
+		from, to = to, from
+	}
+	last.Applied = !last.Applied
+	return Edit{
+		Erase: utf8.RuneCountInString(from + last.Separator),
+		Text:  to + last.Separator,
+	}, true
+}
+
+// Suggest returns the most likely dictionary word for a word that is
+// not in the dictionary.
+func (c *Corrector) Suggest(word string) (string, bool) {
+	if c.Dict == nil {
+		return "", false
+	}
+	src := []rune(strings.ToLower(word))
+	if len(src) < c.MinLength || len(src) < 2 {
+		return "", false
+	}
+	for _, r := range src {
+		if !unicode.IsLetter(r) {
+			return "", false
+		}
+	}
+	if c.Dict.Has(string(src)) {
+		return "", false
+	}
+
+	limit := c.CostPerLetter * float64(len(src))
+	if limit > c.MaxCost {
+		limit = c.MaxCost
+	}
+	best := ""
+	bestCost := limit + 0.001
+	bestFreq := 0
+	// every letter more or less costs at least an insert or delete
+	spread := int(limit / math.Min(CostInsert, CostDelete))
+	for n := len(src) - spread; n <= len(src)+spread; n++ {
+		for _, candidate := range c.Dict.WordsOfLength(n) {
+			cost, ok := c.distance(src, []rune(candidate), bestCost)
+			if !ok {
+				continue
+			}
+			freq := c.Dict.Freq(candidate)
+			if cost < bestCost || (cost == bestCost && freq > bestFreq) {
+				best, bestCost, bestFreq = candidate, cost, freq
+			}
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return matchCase(word, best), true
+}
+
+// Distance is the Damerau-Levenshtein distance between a and b, with
+// substitutions between adjacent bindings weighted lower.
+func (c *Corrector) Distance(a, b []rune) float64 {
+	cost, _ := c.distance(a, b, math.Inf(1))
+	return cost
+}
+
+// distance works out the distance a row at a time, and gives up once
+// two rows in a row are over the limit, since the distance can only
+// grow from there. It takes two because transpositions reach back two
+// rows.
+func (c *Corrector) distance(a, b []rune, limit float64) (float64, bool) {
+	// the last three rows, for transpositions
+	prev2 := make([]float64, len(b)+1)
+	prev := make([]float64, len(b)+1)
+	row := make([]float64, len(b)+1)
+	for j := range prev {
+		prev[j] = float64(j) * CostInsert
+	}
+	prevLowest := 0.0
+	for i := 1; i <= len(a); i++ {
+		row[0] = float64(i) * CostDelete
+		lowest := row[0]
+		for j := 1; j <= len(b); j++ {
+			sub := 0.0
+			if a[i-1] != b[j-1] {
+				sub = CostSubstitute
+				if c.Adjacency.IsNeighbour(a[i-1], b[j-1]) {
+					sub = CostNeighbour
+				}
+			}
+			cost := min3(
+				prev[j]+CostDelete,
+				row[j-1]+CostInsert,
+				prev[j-1]+sub,
+			)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if t := prev2[j-2] + CostTranspose; t < cost {
+					cost = t
+				}
+			}
+			row[j] = cost
+			if cost < lowest {
+				lowest = cost
+			}
+		}
+		if lowest > limit && prevLowest > limit {
+			return lowest, false
+		}
+		prevLowest = lowest
+		prev2, prev, row = prev, row, prev2
+	}
+	return prev[len(b)], prev[len(b)] <= limit
+}
+
+func matchCase(orig, word string) string {
+	runes := []rune(orig)
+	if len(runes) == 0 || !unicode.IsUpper(runes[0]) {
+		return word
+	}
+	if strings.ToUpper(orig) == orig {
+		return strings.ToUpper(word)
+	}
+	w := []rune(word)
+	w[0] = unicode.ToUpper(w[0])
+	return string(w)
+}
+
+func min3(a, b, c float64) float64 {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
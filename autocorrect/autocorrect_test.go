@@ -0,0 +1,83 @@
+package autocorrect
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nvlled/gosn30/dict"
+)
+
+func testCorrector() *Corrector {
+	d := dict.New()
+	for _, w := range []string{"is", "the", "then", "hello", "world", "keyboard"} {
+		d.Add(w, 10)
+	}
+	adj := Adjacency{}
+	adj.LinkRing("h", "l", "n", "i")
+	return New(d, adj)
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+		ok   bool
+	}{
+		{"ls", "", false},
+		{"cd", "", false},
+		{"teh", "the", true},
+		{"Teh", "The", true},
+		{"the", "", false},
+		{"thx", "", false},
+		{"helo", "hello", true},
+		{"wrold", "world", true},
+		{"keybaord", "keyboard", true},
+		{"keybrd", "", false},
+		{"xyzzy", "", false},
+	}
+	c := testCorrector()
+	for _, tt := range tests {
+		got, ok := c.Suggest(tt.word)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Suggest(%q) = %q, %v, want %q, %v", tt.word, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAutoApply(t *testing.T) {
+	c := testCorrector()
+	c.Text("teh")
+	if _, ok := c.KeyPress("space", false); ok {
+		t.Error("correction applied without AutoApply")
+	}
+	if last := c.Last(); last == nil || last.Replacement != "the" || last.Applied {
+		t.Errorf("offered %+v, want the", last)
+	}
+	if edit, ok := c.Toggle(); !ok || edit != (Edit{Erase: 4, Text: "the "}) {
+		t.Errorf("Toggle() = %+v, %v", edit, ok)
+	}
+
+	c.AutoApply = true
+	c.Text("teh")
+	if edit, ok := c.KeyPress("space", false); !ok || edit != (Edit{Erase: 3, Text: "the"}) {
+		t.Errorf("KeyPress() = %+v, %v", edit, ok)
+	}
+}
+
+func TestDistanceLimit(t *testing.T) {
+	c := testCorrector()
+	pairs := [][2]string{
+		{"teh", "the"}, {"abcd", "badc"}, {"hello", "world"},
+		{"ab", "ba"}, {"xy", ""}, {"keybaord", "keyboard"}, {"ilnh", "hnli"},
+	}
+	for _, p := range pairs {
+		a, b := []rune(p[0]), []rune(p[1])
+		want := c.Distance(a, b)
+		for _, limit := range []float64{0, 0.5, 0.7, 1, 1.5, 2, 3} {
+			got, ok := c.distance(a, b, limit)
+			if ok != (want <= limit) || (ok && math.Abs(got-want) > 1e-9) {
+				t.Errorf("distance(%q, %q, %v) = %v, %v, full distance %v", p[0], p[1], limit, got, ok, want)
+			}
+		}
+	}
+}
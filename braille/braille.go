@@ -0,0 +1,212 @@
+package braille
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nvlled/gosn30/gamepad"
+)
+
+// Dots is a braille cell, dot 1 in the lowest bit up to dot 6.
+type Dots uint8
+
+func Cell(dots ...int) Dots {
+	var d Dots
+	for _, dot := range dots {
+		d |= 1 << uint(dot-1)
+	}
+	return d
+}
+
+func (d Dots) String() string {
+	var b strings.Builder
+	for dot := 1; dot <= 6; dot++ {
+		if d&(1<<uint(dot-1)) != 0 {
+			b.WriteByte(byte('0' + dot))
+		}
+	}
+	return b.String()
+}
+
+// DotsOf reads the cell from the controller, Perkins style: L, SL and
+// Y are dots 1-3 for the left hand, R, SR and A are dots 4-6.
+func DotsOf(gpad *gamepad.GamePad) Dots {
+	var d Dots
+	inputs := []bool{
+		gpad.IsButtonDown(gamepad.ButtonL),
+		gpad.IsShoulderDown(gamepad.ShoulderL),
+		gpad.IsButtonDown(gamepad.ButtonY),
+		gpad.IsButtonDown(gamepad.ButtonR),
+		gpad.IsShoulderDown(gamepad.ShoulderR),
+		gpad.IsButtonDown(gamepad.ButtonA),
+	}
+	for i, down := range inputs {
+		if down {
+			d |= 1 << uint(i)
+		}
+	}
+	return d
+}
+
+// Tracker accumulates the dots pressed during a chord. The cell is
+// complete when every dot is released.
+type Tracker struct {
+	dots Dots
+}
+
+func (t *Tracker) Update(down Dots) (Dots, bool) {
+	if down != 0 {
+		t.dots |= down
+		return 0, false
+	}
+	d := t.dots
+	t.dots = 0
+	return d, d != 0
+}
+
+func (t *Tracker) Reset() {
+	t.dots = 0
+}
+
+type Table map[Dots]string
+
+var (
+	CapitalSign = Cell(6)
+	NumberSign  = Cell(3, 4, 5, 6)
+)
+
+// Grade1 is uncontracted braille: letters and punctuation.
+var Grade1 = Table{
+	Cell(1): "a", Cell(1, 2): "b", Cell(1, 4): "c", Cell(1, 4, 5): "d",
+	Cell(1, 5): "e", Cell(1, 2, 4): "f", Cell(1, 2, 4, 5): "g", Cell(1, 2, 5): "h",
+	Cell(2, 4): "i", Cell(2, 4, 5): "j", Cell(1, 3): "k", Cell(1, 2, 3): "l",
+	Cell(1, 3, 4): "m", Cell(1, 3, 4, 5): "n", Cell(1, 3, 5): "o", Cell(1, 2, 3, 4): "p",
+	Cell(1, 2, 3, 4, 5): "q", Cell(1, 2, 3, 5): "r", Cell(2, 3, 4): "s", Cell(2, 3, 4, 5): "t",
+	Cell(1, 3, 6): "u", Cell(1, 2, 3, 6): "v", Cell(2, 4, 5, 6): "w", Cell(1, 3, 4, 6): "x",
+	Cell(1, 3, 4, 5, 6): "y", Cell(1, 3, 5, 6): "z",
+
+	Cell(2): ",", Cell(2, 3): ";", Cell(2, 5): ":", Cell(2, 5, 6): ".",
+	Cell(2, 3, 5): "!", Cell(2, 3, 6): "?", Cell(3): "'", Cell(3, 6): "-",
+}
+
+// Grade2 adds the contractions that stand for letter groups anywhere in
+// a word. It's checked before Grade1.
+var Grade2 = Table{
+	Cell(1, 2, 3, 4, 6): "and", Cell(1, 2, 3, 4, 5, 6): "for", Cell(1, 2, 3, 5, 6): "of",
+	Cell(2, 3, 4, 6): "the", Cell(2, 3, 4, 5, 6): "with",
+
+	Cell(1, 6): "ch", Cell(1, 2, 6): "gh", Cell(1, 4, 6): "sh", Cell(1, 4, 5, 6): "th",
+	Cell(1, 5, 6): "wh", Cell(1, 2, 4, 6): "ed", Cell(1, 2, 4, 5, 6): "er", Cell(1, 2, 5, 6): "ou",
+	Cell(2, 4, 6): "ow", Cell(3, 4): "st", Cell(3, 4, 5): "ar", Cell(3, 4, 6): "ing",
+	Cell(2, 6): "en", Cell(3, 5): "in",
+}
+
+// WordSigns are Grade 2 cells that stand for a whole word when they're
+// written alone.
+var WordSigns = Table{
+	Cell(1, 2): "but", Cell(1, 4): "can", Cell(1, 4, 5): "do", Cell(1, 5): "every",
+	Cell(1, 2, 4): "from", Cell(1, 2, 4, 5): "go", Cell(1, 2, 5): "have", Cell(2, 4, 5): "just",
+	Cell(1, 3): "knowledge", Cell(1, 2, 3): "like", Cell(1, 3, 4): "more", Cell(1, 3, 4, 5): "not",
+	Cell(1, 2, 3, 4): "people", Cell(1, 2, 3, 4, 5): "quite", Cell(1, 2, 3, 5): "rather", Cell(2, 3, 4): "so",
+	Cell(2, 3, 4, 5): "that", Cell(1, 3, 6): "us", Cell(1, 2, 3, 6): "very", Cell(2, 4, 5, 6): "will",
+	Cell(1, 3, 4, 6): "it", Cell(1, 3, 4, 5, 6): "you", Cell(1, 3, 5, 6): "as",
+
+	Cell(1, 6): "child", Cell(1, 4, 6): "shall", Cell(1, 4, 5, 6): "this", Cell(1, 5, 6): "which",
+	Cell(1, 2, 5, 6): "out", Cell(3, 4): "still",
+}
+
+var digits = map[string]string{
+	"a": "1", "b": "2", "c": "3", "d": "4", "e": "5",
+	"f": "6", "g": "7", "h": "8", "i": "9", "j": "0",
+}
+
+// Edit describes how to rewrite typed text: erase Erase characters
+// before the cursor, then type Text.
+type Edit struct {
+	Erase int
+	Text  string
+}
+
+type Translator struct {
+	// Grade2 enables contractions and word signs.
+	Grade2 bool
+
+	capital bool
+	number  bool
+	word    []Dots
+	typed   []rune
+	last    string
+}
+
+// Cell translates a completed cell into the text to type.
+func (t *Translator) Cell(d Dots) (Edit, bool) {
+	switch d {
+	case CapitalSign:
+		t.capital = true
+		return Edit{}, false
+	case NumberSign:
+		t.number = true
+		return Edit{}, false
+	}
+
+	text, ok := "", false
+	if t.number {
+		if letter := Grade1[d]; digits[letter] != "" {
+			text, ok = digits[letter], true
+		} else {
+			t.number = false
+		}
+	}
+	if !ok && t.Grade2 {
+		text, ok = Grade2[d]
+	}
+	if !ok {
+		text, ok = Grade1[d]
+	}
+	if !ok {
+		return Edit{}, false
+	}
+	if t.capital {
+		r, size := utf8.DecodeRuneInString(text)
+		text = strings.ToUpper(string(r)) + text[size:]
+		t.capital = false
+	}
+	t.word = append(t.word, d)
+	t.typed = append(t.typed, []rune(text)...)
+	t.last = text
+	return Edit{Text: text}, true
+}
+
+// Space ends the word. With Grade2, a word sign written alone is
+// replaced by its word.
+func (t *Translator) Space() Edit {
+	edit := Edit{}
+	if t.Grade2 && !t.number && len(t.word) == 1 && string(t.typed) == t.last {
+		if sign, ok := WordSigns[t.word[0]]; ok {
+			if t.last != strings.ToLower(t.last) {
+				sign = strings.ToUpper(sign[:1]) + sign[1:]
+			}
+			edit = Edit{Erase: len(t.typed), Text: sign}
+		}
+	}
+	t.Reset()
+	return edit
+}
+
+// Backspace follows a BackSpace typed while writing a word.
+func (t *Translator) Backspace() {
+	if n := len(t.typed); n > 0 {
+		t.typed = t.typed[:n-1]
+	}
+	if len(t.typed) == 0 {
+		t.word = t.word[:0]
+	}
+}
+
+func (t *Translator) Reset() {
+	t.capital = false
+	t.number = false
+	t.word = t.word[:0]
+	t.typed = t.typed[:0]
+	t.last = ""
+}
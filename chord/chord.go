@@ -0,0 +1,176 @@
+package chord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nvlled/gosn30/gamepad"
+)
+
+// Chord is a set of face buttons and dpad directions, packed the same
+// way as gamepad.State.Chord.
+type Chord uint8
+
+const (
+	FaceB Chord = 1 << gamepad.ButtonB
+	FaceA Chord = 1 << gamepad.ButtonA
+	FaceY Chord = 1 << gamepad.ButtonY
+	FaceX Chord = 1 << gamepad.ButtonX
+
+	DpadLeft  Chord = 1 << (gamepad.DirLeft + 3)
+	DpadRight Chord = 1 << (gamepad.DirRight + 3)
+	DpadUp    Chord = 1 << (gamepad.DirUp + 3)
+	DpadDown  Chord = 1 << (gamepad.DirDown + 3)
+
+	Face = FaceB | FaceA | FaceY | FaceX
+	Dpad = DpadLeft | DpadRight | DpadUp | DpadDown
+)
+
+var keyNames = []struct {
+	key  Chord
+	name string
+}{
+	{FaceY, "Y"}, {FaceX, "X"}, {FaceA, "A"}, {FaceB, "B"},
+	{DpadLeft, "left"}, {DpadUp, "up"}, {DpadRight, "right"}, {DpadDown, "down"},
+}
+
+func (c Chord) String() string {
+	var names []string
+	for _, k := range keyNames {
+		if c&k.key != 0 {
+			names = append(names, k.name)
+		}
+	}
+	return strings.Join(names, "+")
+}
+
+func (c Chord) Keys() int {
+	n := 0
+	for ; c != 0; c &= c - 1 {
+		n++
+	}
+	return n
+}
+
+// Valid reports whether the chord can be pressed: the dpad can't press
+// opposite directions together.
+func (c Chord) Valid() bool {
+	if c == 0 {
+		return false
+	}
+	if c&(DpadLeft|DpadRight) == DpadLeft|DpadRight {
+		return false
+	}
+	return c&(DpadUp|DpadDown) != DpadUp|DpadDown
+}
+
+// effort ranks how hard a chord is to press. Fewer keys are easier,
+// then chords on one cluster, then neighbouring face buttons.
+func (c Chord) effort() int {
+	e := c.Keys() * 10
+	if c&Face != 0 && c&Dpad != 0 {
+		e += 4
+	}
+	face := c & Face
+	if face == FaceY|FaceA || face == FaceX|FaceB {
+		e += 2
+	}
+	return e
+}
+
+// Tracker accumulates the keys pressed during a chord. A chord ends
+// when all of its keys are released.
+type Tracker struct {
+	chord Chord
+}
+
+// Update is called with the keys that are down after every press and
+// release. It returns the chord once everything is released.
+func (t *Tracker) Update(down Chord) (Chord, bool) {
+	down &= Face | Dpad
+	if down != 0 {
+		t.chord |= down
+		return 0, false
+	}
+	c := t.chord
+	t.chord = 0
+	return c, c != 0
+}
+
+func (t *Tracker) Reset() {
+	t.chord = 0
+}
+
+// Map binds chords to keysyms.
+type Map map[Chord]string
+
+// Frequency lists what the default map binds, most frequent first, so
+// the most used characters get the easiest chords.
+var Frequency = []string{
+	"e", "t", "a", "o", "i", "n", "s", "h",
+	"r", "d", "l", "c", "u", "m", "w", "f",
+	"g", "y", "p", "b", "v", "k", "j", "x",
+	"q", "z", "period", "comma", "apostrophe", "question", "exclam", "minus",
+	"1", "2", "3", "4", "5", "6", "7", "8", "9", "0",
+	"colon", "semicolon", "quotedbl", "slash", "parenleft", "parenright",
+	"Tab", "Escape", "Delete", "Left", "Right", "Up", "Down",
+}
+
+// Chords returns every pressable chord, easiest first.
+func Chords() []Chord {
+	var chords []Chord
+	for c := Chord(1); c != 0; c++ {
+		if c.Valid() {
+			chords = append(chords, c)
+		}
+	}
+	sort.SliceStable(chords, func(i, j int) bool {
+		return chords[i].effort() < chords[j].effort()
+	})
+	return chords
+}
+
+func DefaultMap() Map {
+	m := make(Map)
+	chords := Chords()
+	for i, key := range Frequency {
+		if i >= len(chords) {
+			break
+		}
+		m[chords[i]] = key
+	}
+	return m
+}
+
+// Lookup finds the chord for a keysym.
+func (m Map) Lookup(key string) (Chord, bool) {
+	for c, k := range m {
+		if k == key {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// Table renders the map as a training chart, in the order of
+// Frequency.
+func (m Map) Table() string {
+	var b strings.Builder
+	n := 0
+	for _, key := range Frequency {
+		c, ok := m.Lookup(key)
+		if !ok {
+			continue
+		}
+		if n > 0 && n%3 == 0 {
+			b.WriteString("\n")
+		} else if n > 0 {
+			b.WriteString("  ")
+		}
+		fmt.Fprintf(&b, "%-12v %-14v", key, c)
+		n++
+	}
+	b.WriteString("\n")
+	return b.String()
+}
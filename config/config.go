@@ -0,0 +1,257 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+)
+
+const DefaultProfile = "default"
+
+type Config struct {
+	Profiles map[string]*Profile `json:"profiles"`
+	// Rules pick a profile by the active window. The first rule that
+	// matches wins, and the profile gosn30 started with is used when
+	// none do.
+	Rules []Rule `json:"rules"`
+}
+
+// Rule matches windows by regular expressions for the class, either
+// part of WM_CLASS, and the title. Empty fields match anything, and
+// Fullscreen only matches fullscreen windows.
+type Rule struct {
+	Class      string `json:"class"`
+	Title      string `json:"title"`
+	Fullscreen bool   `json:"fullscreen"`
+	Profile    string `json:"profile"`
+
+	class, title *regexp.Regexp
+}
+
+// Compile compiles the rule's expressions. Load does it for the rules
+// in the config file.
+func (r *Rule) Compile() error {
+	var err error
+	if r.Class != "" {
+		if r.class, err = regexp.Compile(r.Class); err != nil {
+			return fmt.Errorf("bad class %q: %v", r.Class, err)
+		}
+	}
+	if r.Title != "" {
+		if r.title, err = regexp.Compile(r.Title); err != nil {
+			return fmt.Errorf("bad title %q: %v", r.Title, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a window with that WM_CLASS and title
+// matches the rule. A rule that isn't compiled matches nothing.
+func (r *Rule) Matches(instance, class, title string, fullscreen bool) bool {
+	if r.Fullscreen && !fullscreen {
+		return false
+	}
+	if r.Class != "" && (r.class == nil || !(r.class.MatchString(instance) || r.class.MatchString(class))) {
+		return false
+	}
+	if r.Title != "" && (r.title == nil || !r.title.MatchString(title)) {
+		return false
+	}
+	return true
+}
+
+// Match returns the profile of the first rule matching a window.
+func (cfg *Config) Match(instance, class, title string, fullscreen bool) (string, bool) {
+	for i := range cfg.Rules {
+		if cfg.Rules[i].Matches(instance, class, title, fullscreen) {
+			return cfg.Rules[i].Profile, true
+		}
+	}
+	return "", false
+}
+
+type Profile struct {
+	// Mode is the mode to switch to with the profile: "keyboard",
+	// "mouse" or "passthrough". Empty leaves the mode alone.
+	Mode string `json:"mode"`
+	// Entry is the text entry method to switch to, like "swipe".
+	Entry string `json:"entry"`
+
+	Mouse     Mouse      `json:"mouse"`
+	Languages []Language `json:"languages"`
+	// Autocorrect fixes misspelt words as soon as they end. Otherwise
+	// corrections are only offered, and applied with @autocorrect.
+	Autocorrect bool `json:"autocorrect"`
+	Scan        Scan `json:"scan"`
+	// Windows names windows for actions to send keys to.
+	Windows map[string]Window `json:"windows"`
+	// Bindings map button combos, like "l+r+y", to keys or actions. The
+	// last button is the one pressed, and the others are held.
+	Bindings map[string]string `json:"bindings"`
+}
+
+// Window picks out a window. Name, Class and ClassName are regular
+// expressions for the title and the two parts of WM_CLASS; empty fields
+// match anything.
+type Window struct {
+	Name        string `json:"name"`
+	Class       string `json:"class"`
+	ClassName   string `json:"classname"`
+	PID         int    `json:"pid"`
+	OnlyVisible bool   `json:"visible"`
+}
+
+// Scan holds the switch scanning options. Durations are in
+// milliseconds.
+type Scan struct {
+	// Speed is how long each row or column stays highlighted.
+	Speed int `json:"speed"`
+	// Dwell is added to the first row after the scan starts or a key is
+	// picked.
+	Dwell int `json:"dwell"`
+	// Button is the switch that picks, named as in Mouse.
+	Button string `json:"button"`
+}
+
+// Language is a layer set to cycle through. Group is the X keyboard
+// group to lock when switching to it, or -1 to leave the group alone.
+type Language struct {
+	Name  string `json:"name"`
+	Group int    `json:"group"`
+}
+
+func (l *Language) UnmarshalJSON(data []byte) error {
+	type language Language
+	*l = Language{Group: -1}
+	return json.Unmarshal(data, (*language)(l))
+}
+
+// Mouse holds the click assist options. Durations are in milliseconds,
+// and 0 disables the option. Buttons are named as in the gamepad, like
+// "x" or "leftstick"; an empty name leaves the action unbound.
+type Mouse struct {
+	// DwellClick clicks after the pointer rests this long.
+	DwellClick int `json:"dwell_click"`
+	// ClickConfirm is how long a click button must be held before it
+	// presses the mouse button.
+	ClickConfirm int `json:"click_confirm"`
+
+	DoubleClick string `json:"double_click"`
+	TripleClick string `json:"triple_click"`
+	DragLock    string `json:"drag_lock"`
+
+	// NextMonitor and PrevMonitor jump the pointer to the middle of the
+	// next or previous monitor, and ConfineMonitor toggles keeping it
+	// on the one it's on.
+	NextMonitor    string `json:"next_monitor"`
+	PrevMonitor    string `json:"prev_monitor"`
+	ConfineMonitor string `json:"confine_monitor"`
+	// MonitorSpeed scales the pointer speed by the monitor's
+	// resolution, compared to the primary monitor.
+	MonitorSpeed bool `json:"monitor_speed"`
+
+	// Acceleration is the pointer curve: "linear", "power" or "ramp".
+	// Speeds are in pixels per second.
+	Acceleration string  `json:"acceleration"`
+	Speed        float64 `json:"speed"`
+	SlowSpeed    float64 `json:"slow_speed"`
+	Exponent     float64 `json:"exponent"`
+	Ramp         int     `json:"ramp"`
+	// Precision is the speed factor with the right trigger fully
+	// pulled or R held.
+	Precision float64 `json:"precision"`
+
+	// ScrollSpeed is in wheel notches per second at full deflection.
+	ScrollSpeed float64 `json:"scroll_speed"`
+	// KineticScroll keeps scrolling after the stick is let go, slowing
+	// down over about this many milliseconds.
+	KineticScroll int `json:"kinetic_scroll"`
+}
+
+func Default() *Config {
+	return &Config{
+		Profiles: map[string]*Profile{
+			DefaultProfile: DefaultProfileSettings(),
+		},
+	}
+}
+
+func DefaultProfileSettings() *Profile {
+	return &Profile{
+		Mouse: Mouse{
+			DoubleClick: "x",
+			TripleClick: "leftstick",
+			DragLock:    "y",
+
+			NextMonitor:  "rightstick",
+			MonitorSpeed: true,
+
+			Acceleration: "linear",
+			Speed:        1000,
+			SlowSpeed:    250,
+			Exponent:     2,
+			Ramp:         600,
+			Precision:    0.35,
+
+			ScrollSpeed: 25,
+		},
+		Scan: Scan{
+			Speed:  800,
+			Dwell:  400,
+			Button: "a",
+		},
+		Languages: []Language{
+			{Name: "english", Group: -1},
+			{Name: "swedish", Group: -1},
+			{Name: "german", Group: -1},
+		},
+	}
+}
+
+// UnmarshalJSON fills in the defaults for the options a profile
+// leaves out.
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	type profile Profile
+	*p = *DefaultProfileSettings()
+	return json.Unmarshal(data, (*profile)(p))
+}
+
+func Path() string {
+	return os.Getenv("HOME") + "/.gosn30.json"
+}
+
+// Load reads the config file. A missing file gives the defaults.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Default(), nil
+	} else if err != nil {
+		return Default(), err
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return Default(), err
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*Profile)
+	}
+	if cfg.Profiles[DefaultProfile] == nil {
+		cfg.Profiles[DefaultProfile] = DefaultProfileSettings()
+	}
+	for i := range cfg.Rules {
+		if err := cfg.Rules[i].Compile(); err != nil {
+			return Default(), fmt.Errorf("rule %v: %v", i+1, err)
+		}
+	}
+	return cfg, nil
+}
+
+// Profile returns the named profile, or the default one.
+func (cfg *Config) Profile(name string) *Profile {
+	if p, ok := cfg.Profiles[name]; ok {
+		return p
+	}
+	return cfg.Profiles[DefaultProfile]
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gen2brain/beeep"
+	"github.com/nvlled/gosn30/autocorrect"
+	"github.com/nvlled/gosn30/braille"
+	"github.com/nvlled/gosn30/chord"
+	"github.com/nvlled/gosn30/config"
+	"github.com/nvlled/gosn30/daisy"
+	"github.com/nvlled/gosn30/dict"
+	"github.com/nvlled/gosn30/gamepad"
+	"github.com/nvlled/gosn30/hud"
+	"github.com/nvlled/gosn30/ibus"
+	"github.com/nvlled/gosn30/inject"
+	"github.com/nvlled/gosn30/layout"
+	"github.com/nvlled/gosn30/morse"
+	"github.com/nvlled/gosn30/mouse"
+	"github.com/nvlled/gosn30/picker"
+	"github.com/nvlled/gosn30/scan"
+	"github.com/nvlled/gosn30/swipe"
+	"github.com/nvlled/gosn30/t9"
+	"github.com/nvlled/gosn30/x11"
+)
+
+const (
+	ModeKeyb = iota
+	ModeMouse
+	// ModePassthrough leaves the gamepad to other programs, like games
+	ModePassthrough
+)
+
+var modeNames = map[string]int{
+	"keyboard":    ModeKeyb,
+	"mouse":       ModeMouse,
+	"passthrough": ModePassthrough,
+}
+
+// text entry methods used in keyboard mode, cycled with L+R+Select
+const (
+	EntryLayout = iota
+	EntryDaisy
+	EntrySwipe
+	EntryMultiTap
+	EntryPredictive
+	EntryChord
+	EntryBraille
+	EntryMorse
+	EntryScan
+)
+
+var entryNames = []string{
+	EntryLayout:     "layout",
+	EntryDaisy:      "daisywheel",
+	EntrySwipe:      "swipe",
+	EntryMultiTap:   "multi-tap",
+	EntryPredictive: "predictive",
+	EntryChord:      "chording",
+	EntryBraille:    "braille",
+	EntryMorse:      "morse",
+	EntryScan:       "scanning",
+}
+
+func entryByName(name string) (int, bool) {
+	for i, n := range entryNames {
+		if n == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Controller turns gamepad events into keys, text and pointer motion,
+// in whichever mode and entry method is on. Output goes through a queue
+// in front of the injector it's made with.
+//
+// Events, profile switches and mode changes are all handled on the
+// goroutine running Run, so the state below has one owner. Only the
+// mode is read from elsewhere, with Mode.
+type Controller struct {
+	gpad  *gamepad.GamePad
+	queue *inject.Queue
+	out   inject.Injector
+
+	// Display shows the grids of the scanner, the symbol picker, the
+	// window switcher and pointer warping. The one a controller starts
+	// with draws nothing.
+	Display *hud.HUD
+	// IME, if set, commits text through IBus while a client has focus.
+	IME *ibus.Engine
+	// Notify announces mode and setting changes.
+	Notify func(title, body string)
+	// Verbose prints what the entry methods make of the input.
+	Verbose bool
+
+	cfg         *config.Config
+	baseProfile string
+	profile     *config.Profile
+	bindings    []binding
+
+	// mode is only changed by setMode, and read with Mode, since
+	// PollMotion reads it off the Run goroutine
+	mode int32
+	// the mode to go back to after passthrough
+	resumeMode int
+	entry      int
+
+	keys     chan *gamepad.Event
+	motion   chan *gamepad.Event
+	profiles chan string
+
+	// L and R presses waiting to see if they start a combo
+	lrPending []*gamepad.Event
+
+	words *dict.Dict
+	// the dictionaries of the languages, loaded when they're first
+	// used; nil for the ones without
+	dicts     map[string]*dict.Dict
+	keyLayout *layout.Layout
+	language  int
+	corrector *autocorrect.Corrector
+
+	wheel *daisy.Wheel
+
+	decoder    *swipe.Decoder
+	trace      *swipe.Trace
+	swipeWords []string
+	swipeIndex int
+	swipeSpace bool
+
+	multiTap   *t9.MultiTap
+	predictor  *t9.Predictor
+	preediting bool
+
+	chords       chord.Map
+	chordTracker *chord.Tracker
+
+	brailleTracker    *braille.Tracker
+	brailleTranslator *braille.Translator
+
+	morseDecoder *morse.Decoder
+	morsePressed uint32
+	morseDown    bool
+	morseUp      time.Time
+
+	scanner    *scan.Scanner
+	scanButton int
+	scanLabels hud.Grid
+
+	symbols *picker.Picker
+	picking bool
+
+	// the window switcher lists the windows on the desktop, with the
+	// one to focus highlighted
+	switchWindows []inject.Window
+	switchNames   []string
+	switchIndex   int
+	switching     bool
+
+	assist   *mouse.Assist
+	pointer  *mouse.Pointer
+	scroller *mouse.Scroller
+
+	// the monitor layout and pointer position are read from X, which
+	// works whatever the output is
+	desk         *x11.Conn
+	monitors     mouse.Monitors
+	monitorSpeed bool
+	confined     bool
+	// where the pointer was last seen, and when
+	pointerX, pointerY int
+	pointerSeen        time.Time
+
+	doubleClickButton, tripleClickButton, dragLockButton int
+	nextMonitorButton, prevMonitorButton, confineButton  int
+
+	// Start in mouse mode divides the screen up to jump the pointer
+	// across it, keynav style
+	warpGrid *mouse.Grid
+}
+
+// NewController makes a controller sending its output to out, set up
+// with the named profile.
+func NewController(out inject.Injector, gpad *gamepad.GamePad, cfg *config.Config, profile string) *Controller {
+	c := &Controller{
+		gpad:        gpad,
+		queue:       inject.NewQueue(out, inject.QueueSize),
+		Display:     &hud.HUD{},
+		Notify:      func(title, body string) { beeep.Notify(title, body, "") },
+		cfg:         cfg,
+		baseProfile: profile,
+		profile:     cfg.Profile(profile),
+		keys:        make(chan *gamepad.Event),
+		motion:      make(chan *gamepad.Event),
+		profiles:    make(chan string),
+	}
+	c.out = c.queue
+
+	if m, ok := modeNames[c.profile.Mode]; ok {
+		c.mode = int32(m)
+	}
+	if e, ok := entryByName(c.profile.Entry); ok {
+		c.entry = e
+	}
+
+	c.words = dict.Default()
+	c.keyLayout = layout.Default
+	if len(c.profile.Languages) > 0 {
+		if l := layout.ByName(c.profile.Languages[0].Name); l != nil {
+			c.keyLayout = l
+		}
+	}
+	c.dicts = map[string]*dict.Dict{layout.Default.Name: c.words}
+	c.corrector = autocorrect.New(c.dictFor(c.keyLayout.Name), layoutAdjacency(c.keyLayout))
+	c.wheel = daisy.New()
+	c.decoder = swipe.NewDecoder(swipe.DefaultLayout, c.words)
+	c.trace = &swipe.Trace{}
+	c.multiTap = t9.NewMultiTap()
+	c.predictor = t9.NewPredictor(c.words)
+	c.chords = chord.DefaultMap()
+	c.chordTracker = &chord.Tracker{}
+	c.brailleTracker = &braille.Tracker{}
+	c.brailleTranslator = &braille.Translator{}
+	c.morseDecoder = morse.NewDecoder()
+	c.morseUp = time.Now()
+	c.scanner = scan.New(scan.DefaultGrid)
+	c.scanLabels = scan.Labels(c.scanner.Grid)
+	c.symbols = picker.New()
+	c.symbols.LoadRecent(picker.RecentPath())
+	c.bindings = parseBindings(c.profile.Bindings)
+
+	c.assist = mouse.NewAssist(c.out, inject.MbLeft)
+	c.pointer = mouse.NewPointer()
+	c.scroller = mouse.NewScroller()
+	if c.queue.CanScroll() {
+		c.scroller.Resolution = inject.WheelStep
+	}
+	c.applyProfile(c.profile)
+	return c
+}
+
+// SetDesktop reads the monitor layout from X, and asks it where the
+// pointer is from then on.
+func (c *Controller) SetDesktop(desk *x11.Conn) {
+	c.desk = desk
+	ms, err := desk.Monitors()
+	if err != nil {
+		fmt.Printf("failed to read the monitors: %v\n", err)
+	}
+	c.monitors = nil
+	for _, m := range ms {
+		c.monitors = append(c.monitors, mouse.Monitor(m))
+		c.debugf("monitor %v: %vx%v at %v,%v\n", m.Name, m.Width, m.Height, m.X, m.Y)
+	}
+	c.monitors.Sort()
+	c.pointerSeen = time.Time{}
+	if x, y, ok := c.queryPointer(); ok {
+		c.updateScale(x, y)
+	}
+}
+
+func (c *Controller) applyProfile(p *config.Profile) {
+	c.assist.DwellClick = time.Duration(p.Mouse.DwellClick) * time.Millisecond
+	c.assist.ClickConfirm = time.Duration(p.Mouse.ClickConfirm) * time.Millisecond
+	c.doubleClickButton = buttonByName(p.Mouse.DoubleClick)
+	c.tripleClickButton = buttonByName(p.Mouse.TripleClick)
+	c.dragLockButton = buttonByName(p.Mouse.DragLock)
+	c.nextMonitorButton = buttonByName(p.Mouse.NextMonitor)
+	c.prevMonitorButton = buttonByName(p.Mouse.PrevMonitor)
+	c.confineButton = buttonByName(p.Mouse.ConfineMonitor)
+	c.monitorSpeed = p.Mouse.MonitorSpeed
+	c.pointer.Scale = 1
+	if c.monitorSpeed {
+		if x, y, ok := c.queryPointer(); ok {
+			c.updateScale(x, y)
+		}
+	}
+	c.pointer.Curve = p.Mouse.Acceleration
+	c.pointer.MaxSpeed = p.Mouse.Speed
+	c.pointer.SlowSpeed = p.Mouse.SlowSpeed
+	c.pointer.Exponent = p.Mouse.Exponent
+	c.pointer.RampTime = time.Duration(p.Mouse.Ramp) * time.Millisecond
+	c.pointer.Precision = p.Mouse.Precision
+	c.scroller.Speed = p.Mouse.ScrollSpeed
+	c.scroller.Momentum = time.Duration(p.Mouse.KineticScroll) * time.Millisecond
+	c.corrector.AutoApply = p.Autocorrect
+	if p.Scan.Speed > 0 {
+		c.scanner.Interval = time.Duration(p.Scan.Speed) * time.Millisecond
+	}
+	if p.Scan.Dwell >= 0 {
+		c.scanner.Dwell = time.Duration(p.Scan.Dwell) * time.Millisecond
+	}
+	c.scanButton = gamepad.ButtonA
+	if b := buttonByName(p.Scan.Button); b >= 0 {
+		c.scanButton = b
+	}
+}
+
+// dictFor returns the dictionary of a language, which is nil and turns
+// correction off for languages without one.
+func (c *Controller) dictFor(lang string) *dict.Dict {
+	d, ok := c.dicts[lang]
+	if !ok {
+		d = dict.ForLanguage(lang)
+		c.dicts[lang] = d
+	}
+	return d
+}
+
+func (c *Controller) notify(title string) {
+	c.Notify(title, "")
+}
+
+func (c *Controller) debugf(format string, args ...interface{}) {
+	if c.Verbose {
+		fmt.Printf(format, args...)
+	}
+}
+
+// releaseHeld lets go of the mouse buttons and modifiers and takes
+// down the HUD and the T9 preedit, so nothing stays held or shown while
+// the gamepad is left alone
+func (c *Controller) releaseHeld() {
+	c.assist.Release()
+	c.scroller.Stop()
+	c.out.SetCtrl(false)
+	c.out.SetShift(false)
+	if c.out.HasModifiers() {
+		c.out.ToggleAlt()
+	}
+	c.warpGrid = nil
+	c.switching = false
+	c.picking = false
+	c.commitT9()
+	// the scanning grid shows again on its next step
+	c.Display.Hide()
+}
+
+// Mode returns the mode the controller is in. It's safe to call from
+// any goroutine.
+func (c *Controller) Mode() int {
+	return int(atomic.LoadInt32(&c.mode))
+}
+
+func (c *Controller) setMode(m int) {
+	mode := c.Mode()
+	if m == mode {
+		return
+	}
+	c.lrPending = nil
+	if mode == ModeMouse {
+		c.assist.Release()
+	}
+	if m == ModePassthrough {
+		c.resumeMode = mode
+		c.releaseHeld()
+		c.gpad.SetGrab(false)
+	} else if mode == ModePassthrough {
+		c.gpad.SetGrab(true)
+	}
+	atomic.StoreInt32(&c.mode, int32(m))
+}
+
+func (c *Controller) togglePassthrough() {
+	if c.Mode() == ModePassthrough {
+		c.setMode(c.resumeMode)
+		c.notify("gosn30 on")
+	} else {
+		c.setMode(ModePassthrough)
+		c.notify("passthrough")
+	}
+}
+
+// switchProfile switches to the profile a rule picked for the active
+// window
+func (c *Controller) switchProfile(name string) {
+	c.profile = c.cfg.Profile(name)
+	c.applyProfile(c.profile)
+	c.bindings = parseBindings(c.profile.Bindings)
+	c.setLanguage(0)
+	if e, ok := entryByName(c.profile.Entry); ok && e != c.entry {
+		c.setEntry(e)
+	}
+	if m, ok := modeNames[c.profile.Mode]; ok {
+		c.setMode(m)
+	} else if c.Mode() == ModePassthrough {
+		c.setMode(c.resumeMode)
+	}
+	c.notify("profile: " + name)
+}
+
+// WatchWindows switches profiles by the active window, if the config
+// has rules for it.
+func (c *Controller) WatchWindows() {
+	if c.desk != nil && len(c.cfg.Rules) > 0 {
+		go watchWindows(c.desk, c.cfg, c.baseProfile, c.profiles)
+	}
+}
+
+// isPassthroughCombo reports whether the event completes L+R+right
+// stick click, which is kept for turning passthrough on and off, so it
+// works in any mode.
+func (c *Controller) isPassthroughCombo(event *gamepad.Event) bool {
+	return event.Pressed && event.IsButton(gamepad.ButtonRightStick) &&
+		c.gpad.IsButtonDown(gamepad.ButtonL) && c.gpad.IsButtonDown(gamepad.ButtonR)
+}
+
+// Poll passes an event from the gamepad on to the goroutine running
+// Run.
+func (c *Controller) Poll(event *gamepad.Event) {
+	c.keys <- event
+}
+
+// PollMotion passes on the stick samples in keyboard mode, where
+// swiping needs every one of them.
+func (c *Controller) PollMotion(event *gamepad.Event) {
+	if c.Mode() == ModeKeyb {
+		c.motion <- event
+	}
+}
+
+// handleEvent handles an event in the mode the controller is in.
+func (c *Controller) handleEvent(event *gamepad.Event) {
+	if c.isPassthroughCombo(event) {
+		c.lrPending = nil
+		c.togglePassthrough()
+		return
+	}
+	switch c.Mode() {
+	case ModeMouse:
+		c.processMouseInput(event)
+	case ModeKeyb:
+		c.processKeyInput(event)
+	}
+}
+
+// Run handles gamepad events, profile switches, the timers of the
+// entry methods and the pointer and scrolling in mouse mode, until the
+// program ends.
+func (c *Controller) Run() {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	for {
+		select {
+		case event := <-c.keys:
+			c.handleEvent(event)
+		case event := <-c.motion:
+			if c.Mode() == ModeKeyb && c.entry == EntrySwipe {
+				c.processKeyInput(event)
+			}
+		case name := <-c.profiles:
+			c.switchProfile(name)
+		case now := <-ticker.C:
+			c.processKeyTick()
+			if c.Mode() == ModeMouse {
+				c.processMouseTick(now)
+			} else {
+				c.scroller.Stop()
+			}
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/nvlled/gosn30/config"
+	"github.com/nvlled/gosn30/dict"
+	"github.com/nvlled/gosn30/gamepad"
+	"github.com/nvlled/gosn30/inject"
+)
+
+// input is a raw joystick event, as the driver sends it.
+type input struct {
+	typ    uint8
+	number uint8
+	value  int16
+}
+
+func press(button uint8) input   { return input{gamepad.JsEventButton, button, 1} }
+func release(button uint8) input { return input{gamepad.JsEventButton, button, 0} }
+
+// the dpad and the shoulders are axes
+var (
+	dpadLeft      = input{gamepad.JsEventAxis, 6, -32767}
+	dpadCenter    = input{gamepad.JsEventAxis, 6, 0}
+	shoulderLDown = input{gamepad.JsEventAxis, 2, 32767}
+)
+
+// isolate points HOME and the system word lists at an empty directory
+// for the length of a test, so it doesn't read the user's files.
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "gosn30-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, words := os.Getenv("HOME"), dict.SystemDir
+	os.Setenv("HOME", dir)
+	dict.SystemDir = dir
+	t.Cleanup(func() {
+		os.Setenv("HOME", home)
+		dict.SystemDir = words
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func newTestController(t *testing.T, cfg *config.Config) (*Controller, *inject.Recorder) {
+	t.Helper()
+	isolate(t)
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	rec := &inject.Recorder{}
+	c := NewController(rec, gamepad.New(), cfg, config.DefaultProfile)
+	c.Notify = func(title, body string) {}
+	return c, rec
+}
+
+// send feeds raw events through the gamepad and the controller the way
+// the gamepad loop would, and returns what got sent out.
+func send(c *Controller, rec *inject.Recorder, inputs ...input) []string {
+	for _, in := range inputs {
+		ev := &gamepad.Event{Type: in.typ, Number: in.number, Value: in.value}
+		if c.gpad.Translate(ev) {
+			c.handleEvent(ev)
+		}
+	}
+	c.queue.Flush()
+	return rec.Actions()
+}
+
+func TestKeyboardMappings(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []input
+		want   []string
+	}{
+		{"Y", []input{press(gamepad.ButtonY)}, []string{"key a"}},
+		{"L held + Y", []input{press(gamepad.ButtonL), press(gamepad.ButtonY)}, []string{"key h"}},
+		{"R held + Y", []input{press(gamepad.ButtonR), press(gamepad.ButtonY)}, []string{"key y"}},
+		{"L released before Y", []input{press(gamepad.ButtonL), release(gamepad.ButtonL), press(gamepad.ButtonY)}, []string{"key a"}},
+		{"dpad left", []input{dpadLeft}, []string{"key d"}},
+		{"L+R held + dpad left", []input{press(gamepad.ButtonL), press(gamepad.ButtonR), dpadLeft}, []string{"key Left"}},
+		{"left shoulder + Y", []input{shoulderLDown, press(gamepad.ButtonY)}, []string{"key 5"}},
+		{"L + left shoulder + A", []input{press(gamepad.ButtonL), shoulderLDown, press(gamepad.ButtonA)}, []string{"key F8"}},
+		{"Start then Y", []input{press(gamepad.ButtonStart), press(gamepad.ButtonY)}, []string{"key A"}},
+		{"left stick click then Y", []input{press(gamepad.ButtonLeftStick), press(gamepad.ButtonY)}, []string{"key Control_L+a"}},
+		{"Y released", []input{press(gamepad.ButtonY), release(gamepad.ButtonY)}, []string{"key a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newTestController(t, nil)
+			if got := send(c, rec, tt.inputs...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombos(t *testing.T) {
+	cfg := config.Default()
+	cfg.Profiles[config.DefaultProfile].Bindings = map[string]string{
+		"l+r+y": "Escape",
+		"r+x":   "Tab",
+	}
+	tests := []struct {
+		name   string
+		inputs []input
+		want   []string
+		mode   int
+		entry  int
+	}{
+		{"binding over the navigation layer", []input{press(gamepad.ButtonL), press(gamepad.ButtonR), press(gamepad.ButtonY)}, []string{"key Escape"}, ModeKeyb, EntryLayout},
+		{"binding over the R layer", []input{press(gamepad.ButtonR), press(gamepad.ButtonX)}, []string{"key Tab"}, ModeKeyb, EntryLayout},
+		{"unbound held button", []input{press(gamepad.ButtonL), press(gamepad.ButtonX)}, []string{"key l"}, ModeKeyb, EntryLayout},
+		{"L+R+Select cycles the entry method", []input{press(gamepad.ButtonL), press(gamepad.ButtonR), press(gamepad.ButtonSelect)}, nil, ModeKeyb, EntryDaisy},
+		{"Select goes to mouse mode", []input{press(gamepad.ButtonSelect)}, nil, ModeMouse, EntryLayout},
+		{"mouse mode dpad", []input{press(gamepad.ButtonSelect), release(gamepad.ButtonSelect), dpadLeft, dpadCenter}, []string{"key Left"}, ModeMouse, EntryLayout},
+		{"mouse mode A", []input{press(gamepad.ButtonSelect), press(gamepad.ButtonA), release(gamepad.ButtonA)}, []string{"down 1", "up 1"}, ModeMouse, EntryLayout},
+		{"mouse mode double click", []input{press(gamepad.ButtonSelect), press(gamepad.ButtonX)}, []string{"click 1", "click 1"}, ModeMouse, EntryLayout},
+		{"Select back to keyboard", []input{press(gamepad.ButtonSelect), release(gamepad.ButtonSelect), press(gamepad.ButtonSelect), press(gamepad.ButtonY)}, []string{"key a"}, ModeKeyb, EntryLayout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newTestController(t, cfg)
+			if got := send(c, rec, tt.inputs...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if c.Mode() != tt.mode {
+				t.Errorf("mode %v, want %v", c.Mode(), tt.mode)
+			}
+			if c.entry != tt.entry {
+				t.Errorf("entry %v, want %v", c.entry, tt.entry)
+			}
+		})
+	}
+}
+
+func TestLRCombos(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  int
+		inputs []input
+		want   []string
+		check  func(c *Controller) bool
+	}{
+		{"L tapped", EntryDaisy, []input{press(gamepad.ButtonL), release(gamepad.ButtonL)}, []string{"key BackSpace"}, nil},
+		{"R tapped", EntryChord, []input{press(gamepad.ButtonR), release(gamepad.ButtonR)}, []string{"key space"}, nil},
+		{"L held into dpad", EntryDaisy, []input{press(gamepad.ButtonL), dpadLeft, release(gamepad.ButtonL)}, []string{"key BackSpace", "key Left"}, nil},
+		{"L held, then R", EntryMorse, []input{press(gamepad.ButtonL), press(gamepad.ButtonR), release(gamepad.ButtonR), release(gamepad.ButtonL)}, []string{"key BackSpace", "key space"}, nil},
+		{"L+R+Select", EntryDaisy, []input{press(gamepad.ButtonL), press(gamepad.ButtonR), press(gamepad.ButtonSelect), release(gamepad.ButtonR), release(gamepad.ButtonL)}, nil,
+			func(c *Controller) bool { return c.entry == EntrySwipe }},
+		{"L+R+Start", EntryChord, []input{press(gamepad.ButtonL), press(gamepad.ButtonR), press(gamepad.ButtonStart), release(gamepad.ButtonR), release(gamepad.ButtonL)}, nil,
+			func(c *Controller) bool { return c.picking }},
+		{"L+R+left stick", EntryMorse, []input{press(gamepad.ButtonR), press(gamepad.ButtonL), press(gamepad.ButtonLeftStick), release(gamepad.ButtonL), release(gamepad.ButtonR)}, nil,
+			func(c *Controller) bool { return c.language == 1 }},
+		{"L+R+right stick", EntryMultiTap, []input{press(gamepad.ButtonL), press(gamepad.ButtonR), press(gamepad.ButtonRightStick)}, nil,
+			func(c *Controller) bool { return c.Mode() == ModePassthrough }},
+		{"passthrough closes the picker", EntryChord, []input{press(gamepad.ButtonL), press(gamepad.ButtonR), press(gamepad.ButtonStart), press(gamepad.ButtonRightStick)}, nil,
+			func(c *Controller) bool { return c.Mode() == ModePassthrough && !c.picking }},
+		{"swipe L+R+Select", EntrySwipe, []input{press(gamepad.ButtonL), press(gamepad.ButtonR), press(gamepad.ButtonSelect)}, nil,
+			func(c *Controller) bool { return c.entry == EntryMultiTap }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newTestController(t, nil)
+			c.setEntry(tt.entry)
+			if got := send(c, rec, tt.inputs...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if tt.check != nil && !tt.check(c) {
+				t.Error("combo didn't take effect")
+			}
+		})
+	}
+}
+
+// TestModeChanges runs passthrough toggles and mode changes from the
+// gamepad side while profiles switch, all through Run. It's meant for
+// go test -race.
+func TestModeChanges(t *testing.T) {
+	cfg := config.Default()
+	game := config.DefaultProfileSettings()
+	game.Mode = "passthrough"
+	mouse := config.DefaultProfileSettings()
+	mouse.Mode = "mouse"
+	cfg.Profiles["game"] = game
+	cfg.Profiles["mouse"] = mouse
+	c, rec := newTestController(t, cfg)
+	go c.Run()
+
+	// the gamepad state is set up front, so only the controller changes
+	// while the events go through
+	translate := func(in input) *gamepad.Event {
+		ev := &gamepad.Event{Type: in.typ, Number: in.number, Value: in.value}
+		c.gpad.Translate(ev)
+		return ev
+	}
+	toggle := translate(press(gamepad.ButtonRightStick))
+	translate(press(gamepad.ButtonL))
+	translate(press(gamepad.ButtonR))
+	idle := translate(release(gamepad.ButtonX))
+	events := []*gamepad.Event{
+		translate(press(gamepad.ButtonSelect)),
+		translate(press(gamepad.ButtonStart)),
+		translate(press(gamepad.ButtonY)),
+		translate(dpadLeft),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			c.Poll(toggle)
+			c.Poll(events[i%len(events)])
+			c.PollMotion(events[i%len(events)])
+			c.Mode()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			c.profiles <- []string{"game", "mouse", config.DefaultProfile}[i%3]
+		}
+	}()
+	wg.Wait()
+
+	// Run takes the next event only once it's done with the last one,
+	// so the state can be read after an event that changes nothing
+	check := func(when string, mode int, profile string) {
+		t.Helper()
+		c.Poll(idle)
+		if got := c.Mode(); got != mode {
+			t.Errorf("%v: mode %v, want %v", when, got, mode)
+		}
+		if c.profile != cfg.Profile(profile) {
+			t.Errorf("%v: not in the %v profile", when, profile)
+		}
+	}
+	c.profiles <- "game"
+	check("game profile", ModePassthrough, "game")
+	c.profiles <- "mouse"
+	check("mouse profile", ModeMouse, "mouse")
+	c.Poll(toggle)
+	check("passthrough on", ModePassthrough, "mouse")
+	c.Poll(toggle)
+	check("passthrough off", ModeMouse, "mouse")
+	c.profiles <- "game"
+	c.profiles <- config.DefaultProfile
+	check("default profile", ModeMouse, config.DefaultProfile)
+	c.queue.Flush()
+	rec.Actions()
+}
+
+func TestLanguageDictionary(t *testing.T) {
+	c, _ := newTestController(t, nil)
+	// the language dictionaries are loaded when they're first needed
+	if err := ioutil.WriteFile(filepath.Join(os.Getenv("HOME"), ".gosn30-words-swedish"), []byte("hej\nkaffe\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	english := c.corrector.Dict
+	c.setLanguage(1)
+	if d := c.corrector.Dict; d == english || d == nil || !d.Has("kaffe") {
+		t.Errorf("swedish didn't get its own dictionary")
+	}
+	if _, ok := c.corrector.Suggest("kaffee"); !ok {
+		t.Errorf("no correction from the swedish words")
+	}
+	c.setLanguage(2)
+	if c.corrector.Dict != nil && c.corrector.Dict.Has("kaffe") {
+		t.Errorf("german kept the swedish dictionary")
+	}
+	c.setLanguage(0)
+	if c.corrector.Dict != english {
+		t.Errorf("english didn't get its dictionary back")
+	}
+}
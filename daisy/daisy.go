@@ -0,0 +1,156 @@
+package daisy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nvlled/gosn30/gamepad"
+)
+
+const Petals = 8
+
+// Deadzone is the stick deflection needed to select a petal or a set.
+const Deadzone = 12000
+
+// A petal holds four characters, one for each face button, in the
+// order Y X A B (clockwise from the left button).
+type Petal [4]string
+
+type Set struct {
+	Name   string
+	Petals [Petals]Petal
+}
+
+var Lower = Set{
+	Name: "lowercase",
+	Petals: [Petals]Petal{
+		{"a", "b", "c", "d"},
+		{"e", "f", "g", "h"},
+		{"i", "j", "k", "l"},
+		{"m", "n", "o", "p"},
+		{"q", "r", "s", "t"},
+		{"u", "v", "w", "x"},
+		{"y", "z", ",", "."},
+		{"?", "!", "'", "-"},
+	},
+}
+
+var Upper = Set{
+	Name: "uppercase",
+	Petals: [Petals]Petal{
+		{"A", "B", "C", "D"},
+		{"E", "F", "G", "H"},
+		{"I", "J", "K", "L"},
+		{"M", "N", "O", "P"},
+		{"Q", "R", "S", "T"},
+		{"U", "V", "W", "X"},
+		{"Y", "Z", ";", ":"},
+		{"\"", "(", ")", "_"},
+	},
+}
+
+var Numbers = Set{
+	Name: "numbers",
+	Petals: [Petals]Petal{
+		{"1", "2", "3", "4"},
+		{"5", "6", "7", "8"},
+		{"9", "0", "+", "-"},
+		{"*", "/", "=", "%"},
+		{"(", ")", "[", "]"},
+		{"{", "}", "<", ">"},
+		{"@", "#", "$", "&"},
+		{"~", "`", "^", "|"},
+	},
+}
+
+var Symbols = Set{
+	Name: "symbols",
+	Petals: [Petals]Petal{
+		{"ä", "ö", "å", "ü"},
+		{"é", "è", "ê", "ë"},
+		{"á", "à", "â", "ã"},
+		{"ó", "ò", "ô", "õ"},
+		{"í", "ì", "ú", "ù"},
+		{"ñ", "ç", "ß", "ø"},
+		{"€", "£", "¥", "°"},
+		{"\\", "…", "–", "—"},
+	},
+}
+
+var Marks = Set{
+	Name: "marks",
+	Petals: [Petals]Petal{
+		{"¡", "¿", "«", "»"},
+		{"§", "¶", "©", "®"},
+		{"™", "±", "×", "÷"},
+		{"≠", "≤", "≥", "≈"},
+		{"½", "¼", "¾", "‰"},
+		{"²", "³", "µ", "√"},
+		{"•", "†", "¢", "∞"},
+		{"‘", "’", "“", "”"},
+	},
+}
+
+// Wheel picks characters from the left stick petal and a face button.
+// The right stick direction selects the set while it's held: neutral,
+// up, right, down and left index Sets in that order.
+type Wheel struct {
+	Sets []Set
+}
+
+func New() *Wheel {
+	return &Wheel{
+		Sets: []Set{Lower, Upper, Numbers, Symbols, Marks},
+	}
+}
+
+// Slot returns the petal slot of a face button, or -1.
+func Slot(button int) int {
+	switch button {
+	case gamepad.ButtonY:
+		return 0
+	case gamepad.ButtonX:
+		return 1
+	case gamepad.ButtonA:
+		return 2
+	case gamepad.ButtonB:
+		return 3
+	}
+	return -1
+}
+
+func (w *Wheel) Petal(left gamepad.Vec) int {
+	return left.Sector(Petals, Deadzone)
+}
+
+func (w *Wheel) Set(right gamepad.Vec) *Set {
+	i := 0
+	if sector := right.Sector(4, Deadzone); sector >= 0 {
+		i = sector + 1
+	}
+	if i >= len(w.Sets) {
+		i = 0
+	}
+	return &w.Sets[i]
+}
+
+func (w *Wheel) Char(left, right gamepad.Vec, button int) (string, bool) {
+	petal := w.Petal(left)
+	slot := Slot(button)
+	if petal < 0 || slot < 0 {
+		return "", false
+	}
+	s := w.Set(right).Petals[petal][slot]
+	return s, s != ""
+}
+
+// Describe shows the selected petal, for printing on petal changes.
+func (w *Wheel) Describe(left, right gamepad.Vec) string {
+	set := w.Set(right)
+	petal := w.Petal(left)
+	if petal < 0 {
+		return set.Name
+	}
+	p := set.Petals[petal]
+	return fmt.Sprintf("%v [%v]", set.Name, strings.Join(p[:], " "))
+}
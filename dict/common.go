@@ -0,0 +1,32 @@
+package dict
+
+var commonWords = []string{
+	"the", "be", "to", "of", "and", "a", "in", "that", "have", "i",
+	"it", "for", "not", "on", "with", "he", "as", "you", "do", "at",
+	"this", "but", "his", "by", "from", "they", "we", "say", "her", "she",
+	"or", "an", "will", "my", "one", "all", "would", "there", "their", "what",
+	"so", "up", "out", "if", "about", "who", "get", "which", "go", "me",
+	"when", "make", "can", "like", "time", "no", "just", "him", "know", "take",
+	"people", "into", "year", "your", "good", "some", "could", "them", "see", "other",
+	"than", "then", "now", "look", "only", "come", "its", "over", "think", "also",
+	"back", "after", "use", "two", "how", "our", "work", "first", "well", "way",
+	"even", "new", "want", "because", "any", "these", "give", "day", "most", "us",
+	"is", "are", "was", "were", "has", "had", "been", "did", "does", "said",
+	"more", "here", "very", "much", "where", "why", "should", "need", "still", "thing",
+	"many", "right", "too", "let", "help", "put", "mean", "keep", "same", "tell",
+	"find", "long", "down", "made", "off", "before", "never", "call", "last", "own",
+	"great", "old", "big", "high", "small", "life", "world", "hand", "part", "place",
+	"case", "week", "point", "number", "group", "problem", "fact", "yes", "thanks", "please",
+	"sorry", "okay", "maybe", "really", "today", "tomorrow", "again", "always", "something", "nothing",
+	"everything", "anything", "someone", "home", "game", "play", "read", "write", "type", "word",
+	"text", "file", "line", "open", "close", "save", "start", "stop", "run", "test",
+	"code", "change", "build", "fix", "send", "show", "try", "ask", "feel", "seem",
+	"leave", "turn", "move", "live", "believe", "hold", "bring", "happen", "set", "love",
+	"name", "next", "while", "without", "between", "under", "around", "every", "each", "both",
+	"few", "those", "such", "through", "during", "against", "already", "though", "might", "must",
+	"little", "better", "best", "sure", "able", "different", "important", "possible", "free", "real",
+	"hello", "hi", "bye", "night", "morning", "evening", "friend", "thank", "went", "got",
+	"going", "doing", "being", "having", "saying", "getting", "making", "looking", "coming", "thinking",
+	"question", "answer", "idea", "reason", "water", "food", "money", "house", "school", "car",
+	"mouse", "keyboard", "screen", "window", "button", "controller", "letter", "key", "left", "space",
+}
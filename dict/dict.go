@@ -0,0 +1,154 @@
+package dict
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Dict struct {
+	freq  map[string]int
+	words []string
+	// words by their length in runes, so lookups for similar words only
+	// go through the ones that can be close
+	byLen map[int][]string
+}
+
+func New() *Dict {
+	return &Dict{freq: make(map[string]int), byLen: make(map[int][]string)}
+}
+
+// SystemDir is where the system word lists are.
+var SystemDir = "/usr/share/dict"
+
+// Default loads the user word list (~/.gosn30-words) and the system
+// word list if they exist, on top of the builtin common words.
+func Default() *Dict {
+	d := Builtin()
+	d.LoadFile(os.Getenv("HOME") + "/.gosn30-words")
+	d.LoadFile(SystemDir + "/words")
+	return d
+}
+
+// wordLists are the system word lists of the languages that have one.
+var wordLists = map[string][]string{
+	"swedish": {"swedish"},
+	"german":  {"ngerman", "ogerman"},
+}
+
+// ForLanguage loads the dictionary of a layout language: Default for
+// english, and otherwise the user word list (~/.gosn30-words-NAME) and
+// the system one. It returns nil if there are no words for it, like for
+// vim.
+func ForLanguage(name string) *Dict {
+	if name == "english" {
+		return Default()
+	}
+	d := New()
+	d.LoadFile(os.Getenv("HOME") + "/.gosn30-words-" + name)
+	for _, file := range wordLists[name] {
+		d.LoadFile(SystemDir + "/" + file)
+	}
+	if d.Len() == 0 {
+		return nil
+	}
+	return d
+}
+
+// Builtin returns a dictionary of common english words. Earlier words
+// in the list get a higher frequency.
+func Builtin() *Dict {
+	d := New()
+	for i, w := range commonWords {
+		d.Add(w, len(commonWords)-i+100)
+	}
+	return d
+}
+
+func (d *Dict) Add(word string, freq int) {
+	word = strings.ToLower(word)
+	if !isWord(word) {
+		return
+	}
+	if n, ok := d.freq[word]; ok {
+		if freq > n {
+			d.freq[word] = freq
+		}
+		return
+	}
+	d.freq[word] = freq
+	d.words = append(d.words, word)
+	n := utf8.RuneCountInString(word)
+	d.byLen[n] = append(d.byLen[n], word)
+}
+
+// LoadFile reads one word per line. A line may have a frequency count
+// after the word, separated by whitespace.
+func (d *Dict) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		freq := 1
+		if len(fields) > 1 {
+			if n, err := strconv.Atoi(fields[1]); err == nil {
+				freq = n
+			}
+		}
+		d.Add(fields[0], freq)
+	}
+	return scanner.Err()
+}
+
+func (d *Dict) Has(word string) bool {
+	_, ok := d.freq[strings.ToLower(word)]
+	return ok
+}
+
+func (d *Dict) Freq(word string) int {
+	return d.freq[strings.ToLower(word)]
+}
+
+func (d *Dict) Len() int {
+	return len(d.words)
+}
+
+func (d *Dict) Words() []string {
+	return d.words
+}
+
+// WordsOfLength returns the words that are n letters long.
+func (d *Dict) WordsOfLength(n int) []string {
+	return d.byLen[n]
+}
+
+// ByFreq sorts words from most to least frequent.
+func (d *Dict) ByFreq(words []string) {
+	sort.SliceStable(words, func(i, j int) bool {
+		return d.freq[words[i]] > d.freq[words[j]]
+	})
+}
+
+func isWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !unicode.IsLetter(c) {
+			return false
+		}
+	}
+	return true
+}
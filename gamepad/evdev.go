@@ -0,0 +1,198 @@
+package gamepad
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	evKey = 0x01
+	evAbs = 0x03
+
+	keyMax   = 0x2ff
+	absCount = 0x40
+	btnMisc  = 0x100
+	btnJoy   = 0x120
+
+	eviocGrab = 0x40044590
+)
+
+func eviocGBit(ev, size uintptr) uintptr {
+	return 2<<30 | size<<16 | 'E'<<8 | (0x20 + ev)
+}
+
+func eviocGAbs(abs uintptr) uintptr {
+	return 2<<30 | unsafe.Sizeof(absInfo{})<<16 | 'E'<<8 | (0x40 + abs)
+}
+
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+type absInfo struct {
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+// axis is an absolute axis as the joystick driver sees it: its number
+// and the default correction scaling it to -32767..32767.
+type axis struct {
+	number uint8
+	center [2]int64
+	coef   int64
+}
+
+func (a axis) correct(v int32) int16 {
+	value := int64(v)
+	if a.coef == 0 {
+		return int16(value)
+	}
+	switch {
+	case value < a.center[0]:
+		value = (a.coef * (value - a.center[0])) >> 14
+	case value > a.center[1]:
+		value = (a.coef * (value - a.center[1])) >> 14
+	default:
+		value = 0
+	}
+	if value < -32767 {
+		value = -32767
+	} else if value > 32767 {
+		value = 32767
+	}
+	return int16(value)
+}
+
+// Evdev reads a gamepad from its event device rather than its joystick
+// device, so it can be grabbed. Events come out as joystick events,
+// with buttons and axes numbered and scaled the way the joystick driver
+// does it.
+type Evdev struct {
+	file    *os.File
+	buttons map[uint16]uint8
+	axes    map[uint16]axis
+}
+
+// OpenEvdev opens the event device behind a joystick device, like
+// "js0".
+func OpenEvdev(js string) (*Evdev, error) {
+	nodes, _ := filepath.Glob("/sys/class/input/" + js + "/device/event*")
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no event device for %v", js)
+	}
+	file, err := os.Open("/dev/input/" + filepath.Base(nodes[0]))
+	if err != nil {
+		return nil, err
+	}
+	d := &Evdev{file: file, buttons: make(map[uint16]uint8), axes: make(map[uint16]axis)}
+	if err = d.probe(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Evdev) ioctl(req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.file.Fd(), req, arg); errno != 0 {
+		return fmt.Errorf("evdev ioctl %#x: %v", req, errno)
+	}
+	return nil
+}
+
+// probe numbers the buttons and axes like joydev: axes in code order,
+// then the joystick and gamepad buttons followed by the misc ones.
+// The loop goes by those numbers, so the left trigger, ABS_Z, has to
+// come out as axis 2 and the right one, ABS_RZ, as axis 5 here too.
+func (d *Evdev) probe() error {
+	var keys [keyMax/8 + 1]byte
+	var abs [absCount / 8]byte
+	if err := d.ioctl(eviocGBit(evKey, uintptr(len(keys))), uintptr(unsafe.Pointer(&keys))); err != nil {
+		return err
+	}
+	if err := d.ioctl(eviocGBit(evAbs, uintptr(len(abs))), uintptr(unsafe.Pointer(&abs))); err != nil {
+		return err
+	}
+	has := func(bits []byte, code int) bool {
+		return bits[code/8]&(1<<(uint(code)%8)) != 0
+	}
+
+	for code := 0; code < absCount; code++ {
+		if !has(abs[:], code) {
+			continue
+		}
+		var info absInfo
+		if err := d.ioctl(eviocGAbs(uintptr(code)), uintptr(unsafe.Pointer(&info))); err != nil {
+			return err
+		}
+		a := axis{number: uint8(len(d.axes))}
+		if info.Maximum != info.Minimum {
+			mid := (int64(info.Maximum) + int64(info.Minimum)) / 2
+			flat := int64(info.Flat)
+			a.center = [2]int64{mid - flat, mid + flat}
+			if t := (int64(info.Maximum)-int64(info.Minimum))/2 - 2*flat; t != 0 {
+				a.coef = (1 << 29) / t
+			}
+		}
+		d.axes[uint16(code)] = a
+	}
+
+	number := func(code int) {
+		if has(keys[:], code) {
+			d.buttons[uint16(code)] = uint8(len(d.buttons))
+		}
+	}
+	for code := btnJoy; code <= keyMax; code++ {
+		number(code)
+	}
+	for code := btnMisc; code < btnJoy; code++ {
+		number(code)
+	}
+	return nil
+}
+
+// Grab takes the device for this program alone, or lets it go.
+func (d *Evdev) Grab(on bool) error {
+	arg := uintptr(0)
+	if on {
+		arg = 1
+	}
+	return d.ioctl(eviocGrab, arg)
+}
+
+// Read returns the next button or axis event, or nil when the device is
+// gone.
+func (d *Evdev) Read() *Event {
+	var ev inputEvent
+	buf := (*[unsafe.Sizeof(ev)]byte)(unsafe.Pointer(&ev))[:]
+	for {
+		if n, err := d.file.Read(buf); err != nil || n < len(buf) {
+			return nil
+		}
+		time := uint32(ev.Time.Sec*1000 + ev.Time.Usec/1000)
+		switch ev.Type {
+		case evKey:
+			// 2 is autorepeat
+			if n, ok := d.buttons[ev.Code]; ok && ev.Value != 2 {
+				return &Event{Type: JsEventButton, Number: n, Value: int16(ev.Value), Time: time}
+			}
+		case evAbs:
+			if a, ok := d.axes[ev.Code]; ok {
+				return &Event{Type: JsEventAxis, Number: a.number, Value: a.correct(ev.Value), Time: time}
+			}
+		}
+	}
+}
+
+func (d *Evdev) Close() error {
+	return d.file.Close()
+}
@@ -1,11 +1,13 @@
 package gamepad
 
 // #include <stdio.h>
+// #include <unistd.h>
 // #include <linux/joystick.h>
-// #include <xdo.h>
-// #cgo LDFLAGS: -lxdo
 import "C"
 import (
+	"fmt"
+	"math"
+	"sync"
 	"unsafe"
 )
 
@@ -33,6 +35,30 @@ func (v *Vec) Normalize() Vec {
 	return w
 }
 
+func (v *Vec) Magnitude() float64 {
+	return math.Hypot(float64(v.X), float64(v.Y))
+}
+
+// Angle returns the direction of the stick in radians, clockwise from up.
+func (v *Vec) Angle() float64 {
+	a := math.Atan2(float64(v.X), -float64(v.Y))
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+// Sector divides the stick range into n equal sectors, clockwise from
+// up, and returns the one the stick points to. It returns -1 when the
+// stick is within the deadzone.
+func (v *Vec) Sector(n int, deadzone float64) int {
+	if n <= 0 || v.Magnitude() < deadzone {
+		return -1
+	}
+	width := 2 * math.Pi / float64(n)
+	return int((v.Angle()+width/2)/width) % n
+}
+
 const (
 	JsEventButton = 0x01
 	JsEventAxis   = 0x02
@@ -82,6 +108,16 @@ type GamePad struct {
 	State     State
 	handlers  []EventHandler
 	LastEvent *Event
+
+	motionHandlers []EventHandler
+
+	// Exclusive makes the loop read the event device rather than the
+	// joystick device, so SetGrab can keep other programs from seeing
+	// the gamepad.
+	Exclusive bool
+	grabMu    sync.Mutex
+	grab      bool
+	evdev     *Evdev
 }
 
 type State struct {
@@ -98,6 +134,21 @@ type State struct {
 
 	LeftStick  Vec
 	RightStick Vec
+	// Triggers are the analog shoulder positions, from -32767
+	// released to 32767 fully pulled.
+	Triggers [2]int16
+}
+
+// Trigger returns how far a shoulder trigger is pulled, from 0 to 1.
+func (s *State) Trigger(shoulder int) float64 {
+	return (float64(s.Triggers[shoulder]) + 32767) / 65534
+}
+
+// Chord packs the face buttons and dpad directions that are down. The
+// low four bits are the face buttons (1 << ButtonB .. 1 << ButtonX) and
+// the high four bits the dpad (DirLeft .. DirDown).
+func (s *State) Chord() uint8 {
+	return s.ButtonFlags&0x0f | (s.DpadFlags>>1&0x0f)<<4
 }
 
 type Event struct {
@@ -105,10 +156,18 @@ type Event struct {
 	Type   uint8
 	Number uint8
 	Value  int16
+	// Time is the driver timestamp in milliseconds.
+	Time uint32
 
 	Pressed    bool
 	InputType  int
 	InputValue int
+
+	// Stick is the position of the moved stick, for analog events.
+	Stick Vec
+
+	emit   bool
+	motion bool
 }
 
 func (ev *Event) SetInput(inputType int, inputValue int) {
@@ -120,6 +179,10 @@ func (ev *Event) IsButton(button int) bool {
 	return ev.InputType == InputButton && ev.InputValue == button
 }
 
+func (ev *Event) IsShoulder(shoulder int) bool {
+	return ev.InputType == InputShoulder && ev.InputValue == shoulder
+}
+
 func (ev *Event) IsDpad(dpad int) bool {
 	if ev.InputType != InputDpad {
 		return false
@@ -141,10 +204,45 @@ func (ev *Event) IsRightAnalog(dir int) bool {
 }
 
 func New() *GamePad {
-	return &GamePad{}
+	gpad := &GamePad{}
+	gpad.State.Triggers = [2]int16{-32767, -32767}
+	return gpad
+}
+
+// SetGrab grabs the gamepad, when Exclusive, or lets it go. It holds
+// across reconnects.
+func (gpad *GamePad) SetGrab(on bool) {
+	gpad.grabMu.Lock()
+	defer gpad.grabMu.Unlock()
+	gpad.grab = on
+	if gpad.evdev != nil {
+		if err := gpad.evdev.Grab(on); err != nil {
+			fmt.Printf("failed to grab the gamepad: %v\n", err)
+		}
+	}
+}
+
+// openEvdev switches reading to the event device, grabbed if asked.
+func (gpad *GamePad) openEvdev() {
+	d, err := OpenEvdev("js0")
+	if err != nil {
+		fmt.Printf("can't grab the gamepad: %v\n", err)
+		return
+	}
+	gpad.grabMu.Lock()
+	defer gpad.grabMu.Unlock()
+	gpad.evdev = d
+	if gpad.grab {
+		if err := d.Grab(true); err != nil {
+			fmt.Printf("failed to grab the gamepad: %v\n", err)
+		}
+	}
 }
 
 func (gpad *GamePad) Read() *Event {
+	if gpad.evdev != nil {
+		return gpad.evdev.Read()
+	}
 
 	var bytes C.ssize_t
 	bytes = C.read(C.int(gpad.FD), unsafe.Pointer(&gpad.event), C.sizeof_struct_js_event)
@@ -156,6 +254,7 @@ func (gpad *GamePad) Read() *Event {
 		Type:   uint8(gpad.event._type),
 		Number: uint8(gpad.event.number),
 		Value:  int16(gpad.event.value),
+		Time:   uint32(gpad.event.time),
 	}
 }
 
@@ -47,6 +47,13 @@ func (gpad *GamePad) Poll(fn EventHandler) {
 	gpad.handlers = append(gpad.handlers, fn)
 }
 
+// PollMotion registers a handler that receives every analog stick
+// sample, not just the ones crossing a direction threshold. The
+// event's Stick has the updated stick position.
+func (gpad *GamePad) PollMotion(fn EventHandler) {
+	gpad.motionHandlers = append(gpad.motionHandlers, fn)
+}
+
 func (gpad *GamePad) SendEvent(ev *Event) {
 	if gpad.eventChannel != nil {
 		gpad.eventChannel <- ev
@@ -63,6 +70,9 @@ func (gpad *GamePad) StartLoop() {
 			continue
 		}
 		gpad.FD = file.Fd()
+		if gpad.Exclusive {
+			gpad.openEvdev()
+		}
 
 		c := make(chan *Event, 1)
 		gpad.eventChannel = c
@@ -73,39 +83,105 @@ func (gpad *GamePad) StartLoop() {
 				if ev == nil {
 					break
 				}
+				if ev.motion {
+					for _, fn := range gpad.motionHandlers {
+						fn(ev)
+					}
+				}
+				if !ev.emit {
+					continue
+				}
 				for _, fn := range gpad.handlers {
 					fn(ev)
 				}
-				fmt.Printf("type=%v, number=%v, value=%v\n", ev.Type, ev.Number, ev.Value)
 			}
 		}()
 
 		for {
-			var ev *Event
-			if ev = gpad.Read(); ev == nil {
+			ev := gpad.Read()
+			if ev == nil {
 				break
 			}
-			emit := true
-			ev.gpad = gpad
-			ev.Pressed = ev.Value != 0
-
-			if ev.Type == JsEventButton {
-				if ev.Number >= 0 && ev.Number <= 9 {
-					gpad.SetButtonState(ev.Number, ev.Value != 0)
-					ev.SetInput(InputButton, int(ev.Number))
-				}
-			} else if ev.Type == JsEventAxis {
-				if ev.Number >= 0 && ev.Number <= 4 {
-					left := ev.Number <= 1
-					horizontal := ev.Number == 0 || ev.Number == 3
-					dir := GetAnalogDirection(horizontal, ev.Value)
-					//dir := GetAnalogYDirection(ev.Value)
-					prevDir := gpad.GetAnalogDirection(left, horizontal)
-					if left {
-						ev.InputType = InputAnalogLeft
-					} else {
-						ev.InputType = InputAnalogRight
-					}
+			if gpad.Translate(ev) {
+				gpad.LastEvent = ev
+			}
+			if ev.emit || ev.motion {
+				c <- ev
+			}
+		}
+
+		close(c)
+		file.Close()
+		gpad.grabMu.Lock()
+		if gpad.evdev != nil {
+			gpad.evdev.Close()
+			gpad.evdev = nil
+		}
+		gpad.grabMu.Unlock()
+		println("Gamepad disconnected!")
+	}
+}
+
+// Translate updates the state from a raw joystick event and fills in
+// what input it is. It reports whether the event goes to the Poll
+// handlers: stick events only do when they cross into or out of a
+// direction, while the PollMotion handlers get every one.
+func (gpad *GamePad) Translate(ev *Event) bool {
+	emit := true
+	ev.gpad = gpad
+	ev.Pressed = ev.Value != 0
+
+	if ev.Type == JsEventButton {
+		if ev.Number >= 0 && ev.Number <= 9 {
+			gpad.SetButtonState(ev.Number, ev.Value != 0)
+			ev.SetInput(InputButton, int(ev.Number))
+		}
+	} else if ev.Type == JsEventAxis {
+		if ev.Number <= 1 || ev.Number == 3 || ev.Number == 4 {
+			left := ev.Number <= 1
+			horizontal := ev.Number == 0 || ev.Number == 3
+			dir := GetAnalogDirection(horizontal, ev.Value)
+			//dir := GetAnalogYDirection(ev.Value)
+			prevDir := gpad.GetAnalogDirection(left, horizontal)
+			if left {
+				ev.InputType = InputAnalogLeft
+			} else {
+				ev.InputType = InputAnalogRight
+			}
+			ev.Pressed = dir != 0
+
+			inputValue := dir
+			if inputValue == 0 {
+				inputValue = prevDir
+			}
+			ev.InputValue = int(inputValue)
+
+			emit = dir != prevDir
+			gpad.SetAnalogState(left, horizontal, ev.Value)
+			if left {
+				ev.Stick = gpad.State.LeftStick
+			} else {
+				ev.Stick = gpad.State.RightStick
+			}
+			ev.motion = true
+			//gpad.State.LeftStick.Y = ev.Value
+			/*
+				} else if ev.Number == 0 { // leftanalogX
+					dir := GetAnalogXDirection(ev.Value)
+					prevDir := GetAnalogXDirection(gpad.State.LeftStick.X)
+					ev.InputType = InputAnalogLeft
+					ev.Pressed = dir != 0
+					ev.InputValue = int(dir)
+
+					emit = dir != prevDir
+					gpad.State.LeftStick.X = ev.Value
+					// TODO: do not emit if same direction
+					//if GetAnalogXDirection(ev.Value) == GetAnalogXDirection(gpad.state.LeftStick.X) && ev.Pressed == gpad.pre
+					//SetAnalogValue(ev, gpad.State.LeftStick.X, DirLeft, DirRight)
+				} else if ev.Number == 1 { // leftanalogY
+					dir := GetAnalogYDirection(ev.Value)
+					prevDir := GetAnalogYDirection(gpad.State.LeftStick.Y)
+					ev.InputType = InputAnalogLeft
 					ev.Pressed = dir != 0
 
 					inputValue := dir
@@ -115,106 +191,72 @@ func (gpad *GamePad) StartLoop() {
 					ev.InputValue = int(inputValue)
 
 					emit = dir != prevDir
-					gpad.SetAnalogState(left, horizontal, ev.Value)
+					gpad.State.LeftStick.Y = ev.Value
+					//fmt.Printf(">ev.Value=%v, emit=%v, dir=%v, gpad.IsLeftAnalog=%v, pressed: %v\n", ev.Value, emit, inputValue, gpad.IsLeftAnalog(dir), ev.Pressed)
+					//SetAnalogValue(ev, gpad.State.LeftStick.Y, DirUp, DirDown)
+					//ev.InputType = InputAnalogLeft
+					//ev.Pressed = ev.Value != 0
 					//gpad.State.LeftStick.Y = ev.Value
-					/*
-						} else if ev.Number == 0 { // leftanalogX
-							dir := GetAnalogXDirection(ev.Value)
-							prevDir := GetAnalogXDirection(gpad.State.LeftStick.X)
-							ev.InputType = InputAnalogLeft
-							ev.Pressed = dir != 0
-							ev.InputValue = int(dir)
-
-							emit = dir != prevDir
-							gpad.State.LeftStick.X = ev.Value
-							// TODO: do not emit if same direction
-							//if GetAnalogXDirection(ev.Value) == GetAnalogXDirection(gpad.state.LeftStick.X) && ev.Pressed == gpad.pre
-							//SetAnalogValue(ev, gpad.State.LeftStick.X, DirLeft, DirRight)
-						} else if ev.Number == 1 { // leftanalogY
-							dir := GetAnalogYDirection(ev.Value)
-							prevDir := GetAnalogYDirection(gpad.State.LeftStick.Y)
-							ev.InputType = InputAnalogLeft
-							ev.Pressed = dir != 0
-
-							inputValue := dir
-							if inputValue == 0 {
-								inputValue = prevDir
-							}
-							ev.InputValue = int(inputValue)
-
-							emit = dir != prevDir
-							gpad.State.LeftStick.Y = ev.Value
-							//fmt.Printf(">ev.Value=%v, emit=%v, dir=%v, gpad.IsLeftAnalog=%v, pressed: %v\n", ev.Value, emit, inputValue, gpad.IsLeftAnalog(dir), ev.Pressed)
-							//SetAnalogValue(ev, gpad.State.LeftStick.Y, DirUp, DirDown)
-							//ev.InputType = InputAnalogLeft
-							//ev.Pressed = ev.Value != 0
-							//gpad.State.LeftStick.Y = ev.Value
-						} else if ev.Number == 3 { // rightanalogX
-							//SetAnalogValue(ev, gpad.State.RightStick.X, DirLeft, DirRight)
-							//ev.InputType = InputAnalogLeft
-							//ev.Pressed = ev.Value != 0
-							//gpad.State.RightStick.X = ev.Value
-						} else if ev.Number == 4 { // rightanalogY
-							//SetAnalogValue(ev, gpad.State.RightStick.Y, DirUp, DirDown)
-							//ev.InputType = InputAnalogLeft
-							//ev.Pressed = ev.Value != 0
-							//gpad.State.RightStick.Y = ev.Value
-					*/
-				} else if ev.Number == 2 {
-					gpad.SetShoulderState(ShoulderL, ev.Value == 32767)
-					ev.SetInput(InputShoulder, ShoulderL)
-					ev.Pressed = ev.Value == 32767
-				} else if ev.Number == 5 {
-					gpad.SetShoulderState(ShoulderR, ev.Value == 32767)
-					ev.SetInput(InputShoulder, ShoulderR)
-					ev.Pressed = ev.Value == 32767
-				} else if ev.Number == 6 {
-					if ev.Value == -32767 {
-						gpad.SetDpadState(DirLeft, true)
-						gpad.SetDpadState(DirRight, false)
-						ev.SetInput(InputDpad, DirLeft)
-					} else if ev.Value == 32767 {
-						gpad.SetDpadState(DirRight, true)
-						gpad.SetDpadState(DirLeft, false)
-						ev.SetInput(InputDpad, DirRight)
-					} else {
-						if gpad.IsButtonDown(DirRight) {
-							ev.SetInput(InputDpad, DirRight)
-						} else if gpad.IsButtonDown(DirLeft) {
-							ev.SetInput(InputDpad, DirLeft)
-						}
-						gpad.SetDpadState(DirRight, false)
-						gpad.SetDpadState(DirLeft, false)
-					}
-				} else if ev.Number == 7 {
-					if ev.Value == -32767 {
-						gpad.SetDpadState(DirUp, true)
-						gpad.SetDpadState(DirDown, false)
-						ev.SetInput(InputDpad, DirUp)
-					} else if ev.Value == 32767 {
-						gpad.SetDpadState(DirDown, true)
-						gpad.SetDpadState(DirUp, false)
-						ev.SetInput(InputDpad, DirDown)
-					} else {
-						if gpad.IsButtonDown(DirUp) {
-							ev.SetInput(InputDpad, DirUp)
-						} else if gpad.IsButtonDown(DirDown) {
-							ev.SetInput(InputDpad, DirDown)
-						}
-						gpad.SetDpadState(DirUp, false)
-						gpad.SetDpadState(DirDown, false)
-					}
+				} else if ev.Number == 3 { // rightanalogX
+					//SetAnalogValue(ev, gpad.State.RightStick.X, DirLeft, DirRight)
+					//ev.InputType = InputAnalogLeft
+					//ev.Pressed = ev.Value != 0
+					//gpad.State.RightStick.X = ev.Value
+				} else if ev.Number == 4 { // rightanalogY
+					//SetAnalogValue(ev, gpad.State.RightStick.Y, DirUp, DirDown)
+					//ev.InputType = InputAnalogLeft
+					//ev.Pressed = ev.Value != 0
+					//gpad.State.RightStick.Y = ev.Value
+			*/
+		} else if ev.Number == 2 {
+			gpad.State.Triggers[ShoulderL] = ev.Value
+			gpad.SetShoulderState(ShoulderL, ev.Value == 32767)
+			ev.SetInput(InputShoulder, ShoulderL)
+			ev.Pressed = ev.Value == 32767
+		} else if ev.Number == 5 {
+			gpad.State.Triggers[ShoulderR] = ev.Value
+			gpad.SetShoulderState(ShoulderR, ev.Value == 32767)
+			ev.SetInput(InputShoulder, ShoulderR)
+			ev.Pressed = ev.Value == 32767
+		} else if ev.Number == 6 {
+			if ev.Value == -32767 {
+				gpad.SetDpadState(DirLeft, true)
+				gpad.SetDpadState(DirRight, false)
+				ev.SetInput(InputDpad, DirLeft)
+			} else if ev.Value == 32767 {
+				gpad.SetDpadState(DirRight, true)
+				gpad.SetDpadState(DirLeft, false)
+				ev.SetInput(InputDpad, DirRight)
+			} else {
+				if gpad.IsButtonDown(DirRight) {
+					ev.SetInput(InputDpad, DirRight)
+				} else if gpad.IsButtonDown(DirLeft) {
+					ev.SetInput(InputDpad, DirLeft)
 				}
+				gpad.SetDpadState(DirRight, false)
+				gpad.SetDpadState(DirLeft, false)
 			}
-
-			if emit && ev != nil {
-				gpad.LastEvent = ev
-				c <- ev
+		} else if ev.Number == 7 {
+			if ev.Value == -32767 {
+				gpad.SetDpadState(DirUp, true)
+				gpad.SetDpadState(DirDown, false)
+				ev.SetInput(InputDpad, DirUp)
+			} else if ev.Value == 32767 {
+				gpad.SetDpadState(DirDown, true)
+				gpad.SetDpadState(DirUp, false)
+				ev.SetInput(InputDpad, DirDown)
+			} else {
+				if gpad.IsButtonDown(DirUp) {
+					ev.SetInput(InputDpad, DirUp)
+				} else if gpad.IsButtonDown(DirDown) {
+					ev.SetInput(InputDpad, DirDown)
+				}
+				gpad.SetDpadState(DirUp, false)
+				gpad.SetDpadState(DirDown, false)
 			}
 		}
-
-		close(c)
-		file.Close()
-		println("Gamepad disconnected!")
 	}
+
+	ev.emit = emit
+	return emit
 }
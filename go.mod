@@ -2,4 +2,7 @@ module github.com/nvlled/gosn30
 
 go 1.14
 
-require github.com/gen2brain/beeep v0.0.0-20200526185328-e9c15c258e28
+require (
+	github.com/gen2brain/beeep v0.0.0-20200526185328-e9c15c258e28
+	github.com/godbus/dbus/v5 v5.0.3
+)
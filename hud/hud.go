@@ -0,0 +1,123 @@
+package hud
+
+import (
+	"github.com/nvlled/gosn30/x11"
+)
+
+// Grid is a table of labels shown by the HUD.
+type Grid [][]string
+
+// fonts are tried in order, the first being one that covers most of
+// Unicode.
+var fonts = []string{
+	"-misc-fixed-medium-r-normal--18-*-*-*-*-*-iso10646-1",
+	"-*-*-medium-r-normal--16-*-*-*-*-*-iso10646-1",
+	"fixed",
+}
+
+// HUD is a small always-on-top window at the bottom of the screen. It
+// isn't safe for concurrent use. Without an X connection, every method
+// does nothing.
+type HUD struct {
+	conn  *x11.Conn
+	win   uint32
+	gc    uint32
+	shown bool
+
+	CellWidth  int
+	CellHeight int
+}
+
+// New makes the HUD window on an X connection, which may be nil.
+func New(conn *x11.Conn) *HUD {
+	h := &HUD{CellWidth: 56, CellHeight: 28}
+	if conn == nil {
+		return h
+	}
+	h.win = conn.NewID()
+	if err := conn.CreateWindow(h.win, 0, 0, 1, 1, conn.Black); err != nil {
+		return h
+	}
+	var font uint32
+	for _, name := range fonts {
+		font = conn.NewID()
+		if conn.OpenFont(font, name) == nil && conn.Sync() == nil {
+			break
+		}
+		font = 0
+	}
+	h.gc = conn.NewID()
+	if conn.CreateGC(h.gc, h.win, font) != nil {
+		return h
+	}
+	h.conn = conn
+	return h
+}
+
+// Show draws the grid under a title line, with the cell at row, col
+// highlighted. A col of -1 highlights the whole row, and a row of -1
+// highlights nothing.
+func (h *HUD) Show(title string, grid Grid, row, col int) {
+	if h.conn == nil {
+		return
+	}
+	cols := 1
+	for _, r := range grid {
+		if len(r) > cols {
+			cols = len(r)
+		}
+	}
+	width := cols * h.CellWidth
+	if w := (len([]rune(title)) + 2) * h.CellWidth / 5; w > width {
+		width = w
+	}
+	height := (len(grid) + 1) * h.CellHeight
+
+	x := (h.conn.Width - width) / 2
+	y := h.conn.Height - height - 2*h.CellHeight
+	h.conn.MoveResizeWindow(h.win, x, y, width, height)
+	if !h.shown {
+		h.conn.MapWindow(h.win)
+		h.shown = true
+	}
+	h.conn.ClearWindow(h.win)
+
+	h.text(title, 4, h.CellHeight-8, false)
+	for i, r := range grid {
+		top := (i + 1) * h.CellHeight
+		for j, label := range r {
+			left := j * h.CellWidth
+			highlight := i == row && (col < 0 || j == col)
+			if highlight {
+				h.conn.SetForeground(h.gc, h.conn.White)
+				h.conn.FillRectangle(h.win, h.gc, left+1, top+1, h.CellWidth-2, h.CellHeight-2)
+			}
+			h.text(label, left+6, top+h.CellHeight-8, highlight)
+		}
+	}
+}
+
+// Message shows a single line of text.
+func (h *HUD) Message(text string) {
+	h.Show(text, nil, -1, -1)
+}
+
+func (h *HUD) Hide() {
+	if h.conn == nil || !h.shown {
+		return
+	}
+	h.conn.UnmapWindow(h.win)
+	h.shown = false
+}
+
+func (h *HUD) text(s string, x, y int, inverted bool) {
+	if s == "" {
+		return
+	}
+	if inverted {
+		h.conn.SetForeground(h.gc, h.conn.Black)
+	} else {
+		h.conn.SetForeground(h.gc, h.conn.White)
+	}
+	h.conn.DrawText(h.win, h.gc, x, y, s)
+}
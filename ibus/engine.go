@@ -0,0 +1,271 @@
+package ibus
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName         = "org.freedesktop.IBus"
+	busPath         = "/org/freedesktop/IBus"
+	factoryPath     = "/org/freedesktop/IBus/Factory"
+	factoryIface    = "org.freedesktop.IBus.Factory"
+	engineIface     = "org.freedesktop.IBus.Engine"
+	serviceIface    = "org.freedesktop.IBus.Service"
+	preeditClear    = 0
+	defaultPageSize = 10
+)
+
+// Engine is gosn30 registered as an IBus input method. Text is only
+// delivered while the engine is enabled and an input context has
+// focus, so callers check Active and fall back to another output
+// otherwise.
+type Engine struct {
+	conn *dbus.Conn
+	// previous is the engine that was in use before, put back by Close
+	previous string
+
+	mu      sync.Mutex
+	path    dbus.ObjectPath
+	engines int
+	enabled bool
+	focused bool
+}
+
+// Connect registers the engine with the IBus daemon at address and
+// makes it the current input method. Close switches back to the one
+// used before.
+func Connect(address string) (*Engine, error) {
+	if address == "" {
+		return nil, fmt.Errorf("no IBus address")
+	}
+	conn, err := dbus.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err = conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	e := &Engine{conn: conn}
+	if err = conn.Export(factory{e}, factoryPath, factoryIface); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reply, err := conn.RequestName(ComponentName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("%v is already taken", ComponentName)
+	}
+	bus := conn.Object(busName, busPath)
+	if err = bus.Call(busName+".RegisterComponent", 0, componentDesc()).Err; err != nil {
+		conn.Close()
+		return nil, err
+	}
+	e.previous = globalEngine(bus)
+	if err = bus.Call(busName+".SetGlobalEngine", 0, EngineName).Err; err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// globalEngine returns the name of the current input method, or "" if
+// there is none. The engine description comes as a variant whose
+// fields differ between IBus versions, but the name is always third.
+func globalEngine(bus dbus.BusObject) string {
+	var desc dbus.Variant
+	if err := bus.Call(busName+".GetGlobalEngine", 0).Store(&desc); err != nil {
+		return ""
+	}
+	fields, ok := desc.Value().([]interface{})
+	if !ok || len(fields) < 3 {
+		return ""
+	}
+	name, _ := fields[2].(string)
+	if name == EngineName {
+		return ""
+	}
+	return name
+}
+
+// Close puts back the input method that was in use before Connect and
+// disconnects.
+func (e *Engine) Close() error {
+	var err error
+	if e.previous != "" {
+		err = e.conn.Object(busName, busPath).Call(busName+".SetGlobalEngine", 0, e.previous).Err
+	}
+	if cerr := e.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Active reports whether committed text will reach a client.
+func (e *Engine) Active() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.path != "" && e.enabled && e.focused
+}
+
+func (e *Engine) emit(signal string, values ...interface{}) error {
+	e.mu.Lock()
+	path := e.path
+	e.mu.Unlock()
+	if path == "" {
+		return fmt.Errorf("no engine created")
+	}
+	return e.conn.Emit(path, engineIface+"."+signal, values...)
+}
+
+// CommitText inserts text into the focused client in one step.
+func (e *Engine) CommitText(s string) error {
+	return e.emit("CommitText", Text(s))
+}
+
+// UpdatePreedit shows text as not yet committed, with the cursor at
+// its end.
+func (e *Engine) UpdatePreedit(s string) error {
+	return e.emit("UpdatePreeditText", Text(s), uint32(len([]rune(s))), s != "", uint32(preeditClear))
+}
+
+func (e *Engine) HidePreedit() error {
+	return e.UpdatePreedit("")
+}
+
+// UpdateCandidates shows the candidate list with the cursor on the
+// chosen candidate.
+func (e *Engine) UpdateCandidates(candidates []string, cursor int) error {
+	return e.emit("UpdateLookupTable", LookupTable(candidates, cursor, defaultPageSize), len(candidates) > 0)
+}
+
+func (e *Engine) HideCandidates() error {
+	return e.UpdateCandidates(nil, 0)
+}
+
+func (e *Engine) create() (dbus.ObjectPath, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.engines++
+	path := dbus.ObjectPath(fmt.Sprintf("%v/Engine/%d", busPath, e.engines))
+	if err := e.conn.Export(service{e, path}, path, engineIface); err != nil {
+		return "", err
+	}
+	if err := e.conn.Export(service{e, path}, path, serviceIface); err != nil {
+		return "", err
+	}
+	e.path = path
+	e.enabled = false
+	e.focused = false
+	return path, nil
+}
+
+func (e *Engine) setEnabled(path dbus.ObjectPath, enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if path == e.path {
+		e.enabled = enabled
+	}
+}
+
+func (e *Engine) setFocused(path dbus.ObjectPath, focused bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if path == e.path {
+		e.focused = focused
+	}
+}
+
+func (e *Engine) destroy(path dbus.ObjectPath) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.conn.Export(nil, path, engineIface)
+	e.conn.Export(nil, path, serviceIface)
+	if path == e.path {
+		e.path = ""
+	}
+}
+
+// factory is called by the daemon to create engine instances.
+type factory struct {
+	e *Engine
+}
+
+func (f factory) CreateEngine(name string) (dbus.ObjectPath, *dbus.Error) {
+	if name != EngineName {
+		return "", dbus.NewError(busName+".Error", []interface{}{"unknown engine " + name})
+	}
+	path, err := f.e.create()
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return path, nil
+}
+
+// service is the engine object seen by the daemon. Key events from the
+// real keyboard are all passed through; gosn30 only sends text.
+type service struct {
+	e    *Engine
+	path dbus.ObjectPath
+}
+
+func (s service) ProcessKeyEvent(keyval, keycode, state uint32) (bool, *dbus.Error) {
+	return false, nil
+}
+
+func (s service) FocusIn() *dbus.Error {
+	s.e.setFocused(s.path, true)
+	return nil
+}
+
+func (s service) FocusOut() *dbus.Error {
+	s.e.setFocused(s.path, false)
+	return nil
+}
+
+func (s service) FocusInId(object, client string) *dbus.Error {
+	return s.FocusIn()
+}
+
+func (s service) FocusOutId(object string) *dbus.Error {
+	return s.FocusOut()
+}
+
+func (s service) Enable() *dbus.Error {
+	s.e.setEnabled(s.path, true)
+	return nil
+}
+
+func (s service) Disable() *dbus.Error {
+	s.e.setEnabled(s.path, false)
+	return nil
+}
+
+func (s service) Destroy() *dbus.Error {
+	s.e.destroy(s.path)
+	return nil
+}
+
+func (s service) Reset() *dbus.Error                                         { return nil }
+func (s service) SetCapabilities(caps uint32) *dbus.Error                    { return nil }
+func (s service) SetCursorLocation(x, y, w, h int32) *dbus.Error             { return nil }
+func (s service) SetSurroundingText(t dbus.Variant, c, a uint32) *dbus.Error { return nil }
+func (s service) PageUp() *dbus.Error                                        { return nil }
+func (s service) PageDown() *dbus.Error                                      { return nil }
+func (s service) CursorUp() *dbus.Error                                      { return nil }
+func (s service) CursorDown() *dbus.Error                                    { return nil }
+func (s service) CandidateClicked(index, button, state uint32) *dbus.Error   { return nil }
+func (s service) PropertyActivate(name string, state uint32) *dbus.Error     { return nil }
+func (s service) PropertyShow(name string) *dbus.Error                       { return nil }
+func (s service) PropertyHide(name string) *dbus.Error                       { return nil }
@@ -0,0 +1,129 @@
+package ibus
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// standIn plays the IBus daemon on a private bus, keeping track of the
+// global engine.
+type standIn struct {
+	mu         sync.Mutex
+	engine     string
+	registered bool
+}
+
+func (s *standIn) RegisterComponent(desc dbus.Variant) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registered = true
+	return nil
+}
+
+func (s *standIn) GetGlobalEngine() (dbus.Variant, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return dbus.MakeVariant(engineDesc{
+		Name:        "IBusEngineDesc",
+		Attachments: map[string]dbus.Variant{},
+		EngineName:  s.engine,
+	}), nil
+}
+
+func (s *standIn) SetGlobalEngine(name string) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.engine = name
+	return nil
+}
+
+func (s *standIn) globalEngine() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.engine
+}
+
+// startBus runs a dbus-daemon with the stand-in on it and returns its
+// address.
+func startBus(t *testing.T, s *standIn) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	dir, err := ioutil.TempDir("", "gosn30-bus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address",
+		"--address=unix:path="+filepath.Join(dir, "bus"))
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	address = strings.TrimSpace(address)
+
+	conn, err := dbus.Dial(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err = conn.Auth(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Hello(); err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Export(s, busPath, busName); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.RequestName(busName, dbus.NameFlagDoNotQueue); err != nil {
+		t.Fatal(err)
+	}
+	return address
+}
+
+func TestConnectRestoresEngine(t *testing.T) {
+	s := &standIn{engine: "xkb:us::eng"}
+	address := startBus(t, s)
+
+	e, err := Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	registered := s.registered
+	s.mu.Unlock()
+	if !registered {
+		t.Error("component not registered")
+	}
+	if got := s.globalEngine(); got != EngineName {
+		t.Errorf("global engine %q after Connect, want %q", got, EngineName)
+	}
+	if err = e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.globalEngine(); got != "xkb:us::eng" {
+		t.Errorf("global engine %q after Close, want xkb:us::eng", got)
+	}
+}
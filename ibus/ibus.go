@@ -0,0 +1,188 @@
+package ibus
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	ComponentName = "org.freedesktop.IBus.Gosn30"
+	EngineName    = "gosn30"
+)
+
+// Address returns the address of the IBus daemon's bus, from
+// IBUS_ADDRESS or the file ibus-daemon writes for the display.
+func Address() string {
+	if addr := os.Getenv("IBUS_ADDRESS"); addr != "" {
+		return addr
+	}
+	file, err := os.Open(addressFile())
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if addr := strings.TrimPrefix(scanner.Text(), "IBUS_ADDRESS="); addr != scanner.Text() {
+			return addr
+		}
+	}
+	return ""
+}
+
+// addressFile is ~/.config/ibus/bus/<machine-id>-<host>-<display>.
+func addressFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	host, display := "unix", "0"
+	if d := os.Getenv("DISPLAY"); d != "" {
+		i := strings.LastIndex(d, ":")
+		if i > 0 {
+			host = d[:i]
+		}
+		display = d[i+1:]
+		if j := strings.Index(display, "."); j >= 0 {
+			display = display[:j]
+		}
+	}
+	return filepath.Join(dir, "ibus", "bus", machineID()+"-"+host+"-"+display)
+}
+
+func machineID() string {
+	for _, path := range []string{"/var/lib/dbus/machine-id", "/etc/machine-id"} {
+		if id, err := ioutil.ReadFile(path); err == nil {
+			return strings.TrimSpace(string(id))
+		}
+	}
+	return ""
+}
+
+// IBus objects are sent as variants of structs whose first fields are
+// the type name and a dictionary of attachments.
+
+type attrList struct {
+	Name        string
+	Attachments map[string]dbus.Variant
+	Attributes  []dbus.Variant
+}
+
+type text struct {
+	Name        string
+	Attachments map[string]dbus.Variant
+	Text        string
+	Attributes  dbus.Variant
+}
+
+type lookupTable struct {
+	Name          string
+	Attachments   map[string]dbus.Variant
+	PageSize      uint32
+	Cursor        uint32
+	CursorVisible bool
+	Round         bool
+	Orientation   int32
+	Candidates    []dbus.Variant
+	Labels        []dbus.Variant
+}
+
+type engineDesc struct {
+	Name          string
+	Attachments   map[string]dbus.Variant
+	EngineName    string
+	LongName      string
+	Description   string
+	Language      string
+	License       string
+	Author        string
+	Icon          string
+	Layout        string
+	Rank          uint32
+	Hotkeys       string
+	Symbol        string
+	Setup         string
+	LayoutVariant string
+	LayoutOption  string
+	Version       string
+	TextDomain    string
+	IconPropKey   string
+}
+
+type component struct {
+	Name          string
+	Attachments   map[string]dbus.Variant
+	ComponentName string
+	Description   string
+	Version       string
+	License       string
+	Author        string
+	Homepage      string
+	Exec          string
+	TextDomain    string
+	ObservedPaths []dbus.Variant
+	Engines       []dbus.Variant
+}
+
+// Text returns an IBusText without attributes.
+func Text(s string) dbus.Variant {
+	return dbus.MakeVariant(text{
+		Name:        "IBusText",
+		Attachments: map[string]dbus.Variant{},
+		Text:        s,
+		Attributes: dbus.MakeVariant(attrList{
+			Name:        "IBusAttrList",
+			Attachments: map[string]dbus.Variant{},
+			Attributes:  []dbus.Variant{},
+		}),
+	})
+}
+
+// LookupTable returns an IBusLookupTable of candidates, with the cursor
+// on the chosen one. Candidates are labelled 1 to 9, then 0.
+func LookupTable(candidates []string, cursor int, pageSize int) dbus.Variant {
+	table := lookupTable{
+		Name:          "IBusLookupTable",
+		Attachments:   map[string]dbus.Variant{},
+		PageSize:      uint32(pageSize),
+		Cursor:        uint32(cursor),
+		CursorVisible: true,
+		Candidates:    []dbus.Variant{},
+		Labels:        []dbus.Variant{},
+	}
+	for _, c := range candidates {
+		table.Candidates = append(table.Candidates, Text(c))
+	}
+	for i := 0; i < pageSize; i++ {
+		table.Labels = append(table.Labels, Text(string(rune('0'+(i+1)%10))))
+	}
+	return dbus.MakeVariant(table)
+}
+
+func componentDesc() dbus.Variant {
+	exec, _ := os.Executable()
+	return dbus.MakeVariant(component{
+		Name:          "IBusComponent",
+		Attachments:   map[string]dbus.Variant{},
+		ComponentName: ComponentName,
+		Description:   "gamepad text entry",
+		Homepage:      "https://github.com/nvlled/gosn30",
+		Exec:          exec,
+		ObservedPaths: []dbus.Variant{},
+		Engines: []dbus.Variant{dbus.MakeVariant(engineDesc{
+			Name:        "IBusEngineDesc",
+			Attachments: map[string]dbus.Variant{},
+			EngineName:  EngineName,
+			LongName:    "gosn30",
+			Description: "gamepad text entry",
+			Language:    "other",
+			Layout:      "default",
+			Symbol:      "🎮",
+		})},
+	})
+}
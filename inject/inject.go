@@ -0,0 +1,131 @@
+package inject
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nvlled/gosn30/uinput"
+	"github.com/nvlled/gosn30/x11"
+)
+
+// Injector sends keys, text and pointer events to the desktop. Mouse
+// buttons are numbered as in X: left, middle, right, then the wheel up,
+// down, left and right.
+type Injector interface {
+	KeyPress(keyseq string)
+	EnterText(text string)
+	SetKeyboardGroup(group int)
+
+	MouseMove(x, y int)
+	MouseDown(mouseButton int)
+	MouseUp(mouseButton int)
+	MouseClick(mouseButton int)
+
+	SetCtrl(val bool)
+	SetShift(val bool)
+	ToggleCapsLock()
+	ToggleCtrl()
+	ToggleAlt()
+	IsCapsLock() bool
+	HasModifiers() bool
+}
+
+// Mouse buttons, numbered as in X.
+const (
+	MbLeft = iota + 1
+	MbMid
+	MbRight
+	MbWheelUp
+	MbWheelDown
+	MbWheelLeft
+	MbWheelRight
+)
+
+// WheelStep is a wheel notch in high-resolution scroll units.
+const WheelStep = 120
+
+// SmoothScroller is implemented by backends that can scroll by
+// fractions of a notch, in 1/120 notch units, right and down being
+// positive.
+type SmoothScroller interface {
+	Scroll(dx, dy int)
+}
+
+// Warper is implemented by backends that can put the pointer at a
+// position on the screen, rather than only move it.
+type Warper interface {
+	MouseMoveTo(x, y int)
+	Screen() (int, int)
+}
+
+// Targeter is implemented by backends that can send keys to a window
+// that doesn't have focus.
+type Targeter interface {
+	FindWindow(s Search) (Window, bool)
+	Target() Window
+	SetTarget(w Window)
+}
+
+// WindowManager is implemented by backends that can ask the window
+// manager to focus, move and close windows, and switch desktops.
+type WindowManager interface {
+	ActiveWindow() (Window, bool)
+	Windows() []Window
+	WindowName(w Window) string
+	ActivateWindow(w Window)
+	MinimizeWindow(w Window)
+	MoveWindowBy(w Window, dx, dy int)
+	ResizeWindowBy(w Window, dw, dh int)
+	ToggleMaximized(w Window)
+	CloseWindow(w Window)
+	Desktop() (int, int)
+	SetDesktop(desktop int)
+	SetWindowDesktop(w Window, desktop int)
+}
+
+// DefaultOrder tries X first, directly, then uinput, which also works
+// on Wayland and the console. Builds with the xdo tag try libxdo before
+// anything else.
+var DefaultOrder = "x11,uinput"
+
+// Backends open an injector by name.
+var Backends = map[string]func() (Injector, error){
+	"x11": func() (Injector, error) {
+		x, err := x11.Open()
+		if err != nil {
+			return nil, err
+		}
+		x.OnError = func(err error) { fmt.Printf("x11: %v\n", err) }
+		return x11Injector{x}, nil
+	},
+	"uinput": func() (Injector, error) {
+		d, err := uinput.Open()
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	},
+}
+
+// Open returns the first backend in a comma separated list that can be
+// opened, and its name.
+func Open(order string) (Injector, string, error) {
+	if order == "" {
+		order = DefaultOrder
+	}
+	var errs []string
+	for _, name := range strings.Split(order, ",") {
+		name = strings.TrimSpace(name)
+		open, ok := Backends[name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%v: unknown backend", name))
+			continue
+		}
+		in, err := open()
+		if err == nil {
+			return in, name, nil
+		}
+		errs = append(errs, fmt.Sprintf("%v: %v", name, err))
+	}
+	return nil, "", fmt.Errorf("no output backend: %v", strings.Join(errs, "; "))
+}
@@ -0,0 +1,300 @@
+package inject
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nvlled/gosn30/modifier"
+)
+
+// QueueSize is how many actions besides pointer moves a queue holds.
+const QueueSize = 256
+
+type action struct {
+	do     func(Injector)
+	move   bool
+	dx, dy int
+}
+
+// Queue is an Injector that hands actions to a worker goroutine, so a
+// slow backend doesn't hold up the gamepad and pointer loops. Actions
+// run in the order they were queued.
+//
+// When the queue is full, the caller waits for room: typing slows down
+// rather than losing or reordering keys. Pointer moves never wait, as
+// a move queued right after another is merged into it, and so they
+// don't count against the size.
+type Queue struct {
+	out  Injector
+	size int
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	items []action
+	count int
+	busy  bool
+
+	// modifier state as of the last queued action, so it can be read
+	// without waiting for the worker
+	mods modifier.State
+}
+
+func NewQueue(out Injector, size int) *Queue {
+	q := &Queue{
+		out:  out,
+		size: size,
+	}
+	if out.IsCapsLock() {
+		q.mods.ToggleCapsLock()
+	}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+func (q *Queue) run() {
+	q.mu.Lock()
+	for {
+		for len(q.items) == 0 {
+			q.busy = false
+			q.cond.Broadcast()
+			q.cond.Wait()
+		}
+		a := q.items[0]
+		q.items = q.items[1:]
+		if !a.move {
+			q.count--
+		}
+		q.busy = true
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		if a.move {
+			q.out.MouseMove(a.dx, a.dy)
+		} else {
+			a.do(q.out)
+		}
+		q.mu.Lock()
+	}
+}
+
+func (q *Queue) push(a action) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if a.move {
+		if n := len(q.items); n > 0 && q.items[n-1].move {
+			q.items[n-1].dx += a.dx
+			q.items[n-1].dy += a.dy
+			return
+		}
+	} else {
+		for q.count >= q.size {
+			q.cond.Wait()
+		}
+		q.count++
+	}
+	q.items = append(q.items, a)
+	q.cond.Broadcast()
+}
+
+// Do queues any action that has to stay in order with the output, such
+// as text committed through another channel.
+func (q *Queue) Do(fn func()) {
+	q.push(action{do: func(Injector) { fn() }})
+}
+
+// Run queues fn with the output, for what the Injector interface
+// doesn't cover, and waits for it to finish.
+func (q *Queue) Run(fn func(Injector)) {
+	done := make(chan struct{})
+	q.push(action{do: func(out Injector) {
+		fn(out)
+		close(done)
+	}})
+	<-done
+}
+
+// Flush waits until every queued action has run.
+func (q *Queue) Flush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) > 0 || q.busy {
+		q.cond.Wait()
+	}
+}
+
+func (q *Queue) KeyPress(keyseq string) {
+	q.push(action{do: func(out Injector) { out.KeyPress(keyseq) }})
+}
+
+func (q *Queue) EnterText(text string) {
+	q.push(action{do: func(out Injector) { out.EnterText(text) }})
+}
+
+func (q *Queue) SetKeyboardGroup(group int) {
+	q.push(action{do: func(out Injector) { out.SetKeyboardGroup(group) }})
+}
+
+func (q *Queue) MouseMove(x, y int) {
+	q.push(action{move: true, dx: x, dy: y})
+}
+
+// CanScroll reports whether the output scrolls smoothly.
+func (q *Queue) CanScroll() bool {
+	_, ok := q.out.(SmoothScroller)
+	return ok
+}
+
+// Scroll scrolls smoothly, if the output can.
+func (q *Queue) Scroll(dx, dy int) {
+	q.push(action{do: func(out Injector) {
+		if s, ok := out.(SmoothScroller); ok {
+			s.Scroll(dx, dy)
+		}
+	}})
+}
+
+// CanWarp reports whether the output can put the pointer at a position.
+func (q *Queue) CanWarp() bool {
+	_, ok := q.out.(Warper)
+	return ok
+}
+
+// MouseMoveTo warps the pointer, if the output can.
+func (q *Queue) MouseMoveTo(x, y int) {
+	q.push(action{do: func(out Injector) {
+		if w, ok := out.(Warper); ok {
+			w.MouseMoveTo(x, y)
+		}
+	}})
+}
+
+// Screen returns the size of the screen, or 0, 0 if the output can't
+// warp the pointer. It's asked from the worker, as backends aren't
+// safe for concurrent use, and so waits for the queue to drain.
+func (q *Queue) Screen() (int, int) {
+	if !q.CanWarp() {
+		return 0, 0
+	}
+	size := make(chan [2]int, 1)
+	q.push(action{do: func(out Injector) {
+		w, h := out.(Warper).Screen()
+		size <- [2]int{w, h}
+	}})
+	s := <-size
+	return s[0], s[1]
+}
+
+// CanManage reports whether the output can manage windows.
+func (q *Queue) CanManage() bool {
+	_, ok := q.out.(WindowManager)
+	return ok
+}
+
+// CanTarget reports whether the output can send keys to a window that
+// doesn't have focus.
+func (q *Queue) CanTarget() bool {
+	_, ok := q.out.(Targeter)
+	return ok
+}
+
+// SendTo runs fn with the output going to the first window matching s,
+// then sends it back where it went before. Nothing is sent if there's
+// no such window.
+func (q *Queue) SendTo(s Search, fn func(Injector)) {
+	q.push(action{do: func(out Injector) {
+		t, ok := out.(Targeter)
+		if !ok {
+			return
+		}
+		w, ok := t.FindWindow(s)
+		if !ok {
+			fmt.Printf("no window with %v\n", s)
+			return
+		}
+		prev := t.Target()
+		t.SetTarget(w)
+		fn(out)
+		t.SetTarget(prev)
+	}})
+}
+
+// LockTo sends all output to the first window matching s until
+// Unlock.
+func (q *Queue) LockTo(s Search) {
+	q.push(action{do: func(out Injector) {
+		t, ok := out.(Targeter)
+		if !ok {
+			return
+		}
+		if w, ok := t.FindWindow(s); ok {
+			t.SetTarget(w)
+		} else {
+			fmt.Printf("no window with %v\n", s)
+		}
+	}})
+}
+
+// Unlock sends output to the focused window again.
+func (q *Queue) Unlock() {
+	q.push(action{do: func(out Injector) {
+		if t, ok := out.(Targeter); ok {
+			t.SetTarget(CurrentWindow)
+		}
+	}})
+}
+
+func (q *Queue) MouseDown(mouseButton int) {
+	q.push(action{do: func(out Injector) { out.MouseDown(mouseButton) }})
+}
+
+func (q *Queue) MouseUp(mouseButton int) {
+	q.push(action{do: func(out Injector) { out.MouseUp(mouseButton) }})
+}
+
+func (q *Queue) MouseClick(mouseButton int) {
+	q.push(action{do: func(out Injector) { out.MouseClick(mouseButton) }})
+}
+
+func (q *Queue) setModifiers(fn func()) {
+	q.mu.Lock()
+	fn()
+	q.mu.Unlock()
+}
+
+func (q *Queue) SetCtrl(val bool) {
+	q.setModifiers(func() { q.mods.SetCtrl(val) })
+	q.push(action{do: func(out Injector) { out.SetCtrl(val) }})
+}
+
+func (q *Queue) SetShift(val bool) {
+	q.setModifiers(func() { q.mods.SetShift(val) })
+	q.push(action{do: func(out Injector) { out.SetShift(val) }})
+}
+
+func (q *Queue) ToggleCapsLock() {
+	q.setModifiers(func() { q.mods.ToggleCapsLock() })
+	q.push(action{do: func(out Injector) { out.ToggleCapsLock() }})
+}
+
+func (q *Queue) ToggleCtrl() {
+	q.setModifiers(func() { q.mods.ToggleCtrl() })
+	q.push(action{do: func(out Injector) { out.ToggleCtrl() }})
+}
+
+func (q *Queue) ToggleAlt() {
+	q.setModifiers(func() { q.mods.ToggleAlt() })
+	q.push(action{do: func(out Injector) { out.ToggleAlt() }})
+}
+
+func (q *Queue) IsCapsLock() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.mods.IsCapsLock()
+}
+
+func (q *Queue) HasModifiers() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.mods.HasModifiers()
+}
@@ -0,0 +1,127 @@
+package inject
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/nvlled/gosn30/modifier"
+)
+
+// Recorder is an Injector that writes down what it's asked to do
+// instead of doing it, for tests and dry runs. Modifiers are applied
+// the same way as the real backends, so "key Control_L+c" is recorded
+// for c typed while ctrl is toggled on.
+type Recorder struct {
+	// Out, if set, gets each action as a line when it happens.
+	Out io.Writer
+
+	mu      sync.Mutex
+	actions []string
+	mods    modifier.State
+}
+
+func (r *Recorder) record(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	action := fmt.Sprintf(format, args...)
+	r.actions = append(r.actions, action)
+	if r.Out != nil {
+		fmt.Fprintln(r.Out, action)
+	}
+}
+
+// Actions returns the recorded actions and forgets them.
+func (r *Recorder) Actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actions := r.actions
+	r.actions = nil
+	return actions
+}
+
+func (r *Recorder) KeyPress(keyseq string) {
+	r.mu.Lock()
+	keyseq = r.mods.Apply(keyseq)
+	r.mu.Unlock()
+	r.record("key %v", keyseq)
+}
+
+func (r *Recorder) EnterText(text string) {
+	r.record("text %v", text)
+}
+
+func (r *Recorder) SetKeyboardGroup(group int) {
+	r.record("group %v", group)
+}
+
+func (r *Recorder) MouseMove(x, y int) {
+	r.record("move %v,%v", x, y)
+}
+
+func (r *Recorder) MouseMoveTo(x, y int) {
+	r.record("warp %v,%v", x, y)
+}
+
+// Screen pretends to be a 1920x1080 screen.
+func (r *Recorder) Screen() (int, int) {
+	return 1920, 1080
+}
+
+func (r *Recorder) Scroll(dx, dy int) {
+	r.record("scroll %v,%v", dx, dy)
+}
+
+func (r *Recorder) MouseDown(mouseButton int) {
+	r.record("down %v", mouseButton)
+}
+
+func (r *Recorder) MouseUp(mouseButton int) {
+	r.record("up %v", mouseButton)
+}
+
+func (r *Recorder) MouseClick(mouseButton int) {
+	r.record("click %v", mouseButton)
+}
+
+func (r *Recorder) SetCtrl(val bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mods.SetCtrl(val)
+}
+
+func (r *Recorder) SetShift(val bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mods.SetShift(val)
+}
+
+func (r *Recorder) ToggleCapsLock() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mods.ToggleCapsLock()
+}
+
+func (r *Recorder) ToggleCtrl() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mods.ToggleCtrl()
+}
+
+func (r *Recorder) ToggleAlt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mods.ToggleAlt()
+}
+
+func (r *Recorder) IsCapsLock() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mods.IsCapsLock()
+}
+
+func (r *Recorder) HasModifiers() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mods.HasModifiers()
+}
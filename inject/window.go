@@ -0,0 +1,40 @@
+package inject
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Window is an X window.
+type Window int
+
+// CurrentWindow stands for the focused window when targeting.
+const CurrentWindow Window = 0
+
+// Search describes the windows to look for. Name, Class and ClassName
+// are regular expressions matched against the title and the two parts
+// of WM_CLASS. Empty fields and a PID of 0 match any window.
+type Search struct {
+	Name        string
+	Class       string
+	ClassName   string
+	PID         int
+	OnlyVisible bool
+}
+
+func (s Search) String() string {
+	var parts []string
+	if s.Name != "" {
+		parts = append(parts, "name="+s.Name)
+	}
+	if s.Class != "" {
+		parts = append(parts, "class="+s.Class)
+	}
+	if s.ClassName != "" {
+		parts = append(parts, "classname="+s.ClassName)
+	}
+	if s.PID != 0 {
+		parts = append(parts, "pid="+strconv.Itoa(s.PID))
+	}
+	return strings.Join(parts, " ")
+}
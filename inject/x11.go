@@ -0,0 +1,68 @@
+package inject
+
+import (
+	"github.com/nvlled/gosn30/x11"
+)
+
+// x11Injector adapts the X injector to the window types here.
+type x11Injector struct {
+	*x11.Injector
+}
+
+func (x x11Injector) FindWindow(s Search) (Window, bool) {
+	w, ok := x.Injector.FindWindow(x11.Search(s))
+	return Window(w), ok
+}
+
+func (x x11Injector) Target() Window {
+	return Window(x.Injector.Target())
+}
+
+func (x x11Injector) SetTarget(w Window) {
+	x.Injector.SetTarget(uint32(w))
+}
+
+func (x x11Injector) ActiveWindow() (Window, bool) {
+	w, ok := x.Injector.ActiveWindow()
+	return Window(w), ok
+}
+
+func (x x11Injector) Windows() []Window {
+	var windows []Window
+	for _, w := range x.Injector.Windows() {
+		windows = append(windows, Window(w))
+	}
+	return windows
+}
+
+func (x x11Injector) WindowName(w Window) string {
+	return x.Injector.WindowName(uint32(w))
+}
+
+func (x x11Injector) ActivateWindow(w Window) {
+	x.Injector.ActivateWindow(uint32(w))
+}
+
+func (x x11Injector) MinimizeWindow(w Window) {
+	x.Injector.MinimizeWindow(uint32(w))
+}
+
+func (x x11Injector) MoveWindowBy(w Window, dx, dy int) {
+	x.Injector.MoveWindowBy(uint32(w), dx, dy)
+}
+
+func (x x11Injector) ResizeWindowBy(w Window, dw, dh int) {
+	x.Injector.ResizeWindowBy(uint32(w), dw, dh)
+}
+
+func (x x11Injector) ToggleMaximized(w Window) {
+	x.Injector.ToggleMaximized(uint32(w))
+}
+
+func (x x11Injector) CloseWindow(w Window) {
+	x.Injector.CloseWindow(uint32(w))
+}
+
+func (x x11Injector) SetWindowDesktop(w Window, desktop int) {
+	x.Injector.SetWindowDesktop(uint32(w), desktop)
+}
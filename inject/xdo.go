@@ -0,0 +1,83 @@
+//go:build xdo
+// +build xdo
+
+package inject
+
+import (
+	"github.com/nvlled/gosn30/xdo"
+)
+
+// xdoInjector adapts libxdo to the window types here, so that only
+// builds with the xdo tag need cgo and libxdo.
+type xdoInjector struct {
+	*xdo.Xdo
+}
+
+func init() {
+	DefaultOrder = "xdo," + DefaultOrder
+	Backends["xdo"] = func() (Injector, error) {
+		x, err := xdo.Open()
+		if err != nil {
+			return nil, err
+		}
+		return xdoInjector{x}, nil
+	}
+}
+
+func (x xdoInjector) FindWindow(s Search) (Window, bool) {
+	w, ok := x.Xdo.FindWindow(xdo.Search(s))
+	return Window(w), ok
+}
+
+func (x xdoInjector) Target() Window {
+	return Window(x.Xdo.Target())
+}
+
+func (x xdoInjector) SetTarget(w Window) {
+	x.Xdo.SetTarget(xdo.Window(w))
+}
+
+func (x xdoInjector) ActiveWindow() (Window, bool) {
+	w, ok := x.Xdo.ActiveWindow()
+	return Window(w), ok
+}
+
+func (x xdoInjector) Windows() []Window {
+	var windows []Window
+	for _, w := range x.Xdo.Windows() {
+		windows = append(windows, Window(w))
+	}
+	return windows
+}
+
+func (x xdoInjector) WindowName(w Window) string {
+	return x.Xdo.WindowName(xdo.Window(w))
+}
+
+func (x xdoInjector) ActivateWindow(w Window) {
+	x.Xdo.ActivateWindow(xdo.Window(w))
+}
+
+func (x xdoInjector) MinimizeWindow(w Window) {
+	x.Xdo.MinimizeWindow(xdo.Window(w))
+}
+
+func (x xdoInjector) MoveWindowBy(w Window, dx, dy int) {
+	x.Xdo.MoveWindowBy(xdo.Window(w), dx, dy)
+}
+
+func (x xdoInjector) ResizeWindowBy(w Window, dw, dh int) {
+	x.Xdo.ResizeWindowBy(xdo.Window(w), dw, dh)
+}
+
+func (x xdoInjector) ToggleMaximized(w Window) {
+	x.Xdo.ToggleMaximized(xdo.Window(w))
+}
+
+func (x xdoInjector) CloseWindow(w Window) {
+	x.Xdo.CloseWindow(xdo.Window(w))
+}
+
+func (x xdoInjector) SetWindowDesktop(w Window, desktop int) {
+	x.Xdo.SetWindowDesktop(xdo.Window(w), desktop)
+}
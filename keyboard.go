@@ -0,0 +1,713 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nvlled/gosn30/autocorrect"
+	"github.com/nvlled/gosn30/braille"
+	"github.com/nvlled/gosn30/chord"
+	"github.com/nvlled/gosn30/gamepad"
+	"github.com/nvlled/gosn30/hud"
+	"github.com/nvlled/gosn30/layout"
+	"github.com/nvlled/gosn30/morse"
+	"github.com/nvlled/gosn30/picker"
+	"github.com/nvlled/gosn30/swipe"
+	"github.com/nvlled/gosn30/t9"
+)
+
+// enterText commits text through IBus when a client has focus, since
+// that's atomic, and types it otherwise
+func (c *Controller) enterText(text string) {
+	if ime := c.IME; ime != nil && ime.Active() {
+		c.queue.Do(func() { ime.CommitText(text) })
+	} else {
+		c.out.EnterText(text)
+	}
+}
+
+func (c *Controller) applyEdit(edit autocorrect.Edit) {
+	for i := 0; i < edit.Erase; i++ {
+		c.out.KeyPress("BackSpace")
+	}
+	if edit.Text != "" {
+		c.enterText(edit.Text)
+	}
+}
+
+func (c *Controller) typeKey(key string) {
+	if c.out.HasModifiers() {
+		c.corrector.Reset()
+	} else if edit, ok := c.corrector.KeyPress(key, c.out.IsCapsLock()); ok {
+		c.applyEdit(edit)
+	} else if last := c.corrector.Last(); last != nil && !last.Applied {
+		c.Notify("did you mean", last.Replacement)
+	}
+	c.out.KeyPress(key)
+}
+
+func (c *Controller) typeText(text string) {
+	c.corrector.Text(text)
+	c.enterText(text)
+}
+
+func (c *Controller) setLanguage(i int) *layout.Layout {
+	if len(c.profile.Languages) == 0 {
+		return nil
+	}
+	c.language = i % len(c.profile.Languages)
+	lang := c.profile.Languages[c.language]
+	l := layout.ByName(lang.Name)
+	if l == nil {
+		fmt.Printf("unknown layout: %v\n", lang.Name)
+		return nil
+	}
+	c.keyLayout = l
+	c.corrector.Reset()
+	c.corrector.Dict = c.dictFor(l.Name)
+	c.corrector.Adjacency = layoutAdjacency(l)
+	if lang.Group >= 0 {
+		c.out.SetKeyboardGroup(lang.Group)
+	}
+	return l
+}
+
+func (c *Controller) switchLanguage(i int) {
+	if l := c.setLanguage(i); l != nil {
+		c.notify(l.Name)
+	}
+}
+
+func (c *Controller) runKey(key string) {
+	switch {
+	case key == "":
+	case key == layout.ActionAutocorrect:
+		if edit, ok := c.corrector.Toggle(); ok {
+			c.applyEdit(edit)
+		}
+	case layout.IsAction(key):
+		c.runAction(key)
+	case layout.IsText(key):
+		c.typeText(key)
+	default:
+		c.typeKey(key)
+	}
+}
+
+func (c *Controller) processDaisyInput(event *gamepad.Event) {
+	gpad := c.gpad
+	if event.InputType == gamepad.InputAnalogLeft || event.InputType == gamepad.InputAnalogRight {
+		c.debugf("daisy: %v\n", c.wheel.Describe(gpad.State.LeftStick, gpad.State.RightStick))
+		return
+	}
+	if !event.Pressed {
+		return
+	}
+	if event.IsButton(gamepad.ButtonL) {
+		c.typeKey("BackSpace")
+	} else if event.IsButton(gamepad.ButtonR) {
+		c.typeKey("space")
+	} else if event.IsShoulder(gamepad.ShoulderR) {
+		c.typeKey("Return")
+	} else if event.IsShoulder(gamepad.ShoulderL) {
+		c.typeKey("Tab")
+	} else if event.IsButton(gamepad.ButtonSelect) {
+		c.setMode(ModeMouse)
+		c.notify("mouse")
+	} else if event.IsDpad(gamepad.DirLeft) {
+		c.typeKey("Left")
+	} else if event.IsDpad(gamepad.DirUp) {
+		c.typeKey("Up")
+	} else if event.IsDpad(gamepad.DirDown) {
+		c.typeKey("Down")
+	} else if event.IsDpad(gamepad.DirRight) {
+		c.typeKey("Right")
+	} else if event.InputType == gamepad.InputButton {
+		if s, ok := c.wheel.Char(gpad.State.LeftStick, gpad.State.RightStick, event.InputValue); ok {
+			c.typeText(s)
+		}
+	}
+}
+
+func (c *Controller) endSwipe() {
+	path := c.trace.Path()
+	c.swipeWords = c.decoder.Decode(path)
+	c.swipeIndex = 0
+	c.debugf("swipe: %v -> %v\n", path, c.swipeWords)
+	c.trace.Reset()
+	if len(c.swipeWords) == 0 {
+		return
+	}
+	if c.swipeSpace {
+		c.typeKey("space")
+	}
+	c.typeText(c.swipeWords[0])
+	c.swipeSpace = true
+}
+
+func (c *Controller) cycleSwipe(step int) {
+	if len(c.swipeWords) < 2 {
+		return
+	}
+	prev := c.swipeWords[c.swipeIndex]
+	c.swipeIndex = (c.swipeIndex + step + len(c.swipeWords)) % len(c.swipeWords)
+	c.corrector.Reset()
+	c.applyEdit(autocorrect.Edit{
+		Erase: len([]rune(prev)),
+		Text:  c.swipeWords[c.swipeIndex],
+	})
+}
+
+func (c *Controller) processSwipeInput(event *gamepad.Event) {
+	if event.InputType == gamepad.InputAnalogLeft {
+		if sector := swipe.DefaultLayout.Sector(event.Stick); sector >= 0 {
+			c.trace.Add(sector)
+		} else if c.trace.Len() > 0 && !c.gpad.IsShoulderDown(gamepad.ShoulderR) {
+			c.endSwipe()
+		}
+		return
+	}
+	if event.IsShoulder(gamepad.ShoulderR) {
+		if !event.Pressed && c.trace.Len() > 0 {
+			c.endSwipe()
+		}
+		return
+	}
+	if !event.Pressed {
+		return
+	}
+	if event.IsDpad(gamepad.DirLeft) {
+		c.cycleSwipe(-1)
+		return
+	} else if event.IsDpad(gamepad.DirRight) {
+		c.cycleSwipe(1)
+		return
+	}
+
+	c.swipeWords = nil
+	c.swipeSpace = false
+	if event.IsButton(gamepad.ButtonL) {
+		c.typeKey("BackSpace")
+	} else if event.IsButton(gamepad.ButtonR) {
+		c.typeKey("space")
+	} else if event.IsButton(gamepad.ButtonA) {
+		c.typeKey("Return")
+	} else if event.IsButton(gamepad.ButtonY) {
+		c.typeKey("period")
+	} else if event.IsButton(gamepad.ButtonX) {
+		c.typeKey("comma")
+	} else if event.IsButton(gamepad.ButtonB) {
+		c.typeKey("apostrophe")
+	} else if event.IsDpad(gamepad.DirUp) {
+		c.typeKey("Up")
+	} else if event.IsDpad(gamepad.DirDown) {
+		c.typeKey("Down")
+	} else if event.IsButton(gamepad.ButtonSelect) {
+		c.setMode(ModeMouse)
+		c.notify("mouse")
+	}
+}
+
+func (c *Controller) typeT9(edit t9.Edit) {
+	if c.out.IsCapsLock() {
+		edit.Text = strings.ToUpper(edit.Text)
+	}
+	c.applyEdit(autocorrect.Edit(edit))
+}
+
+// With IBus, the predicted word is shown as preedit with the other
+// matches as candidates, and only committed once it's done.
+func (c *Controller) predictT9(prev string, edit t9.Edit) {
+	ime := c.IME
+	if ime == nil || !ime.Active() || (prev == "" && c.predictor.Word() == "") {
+		c.typeT9(edit)
+		return
+	}
+	word := c.predictor.Word()
+	if c.out.IsCapsLock() {
+		word = strings.ToUpper(word)
+	}
+	matches, choice := c.predictor.Matches(), c.predictor.Choice()
+	c.queue.Do(func() {
+		ime.UpdatePreedit(word)
+		ime.UpdateCandidates(matches, choice)
+	})
+	c.preediting = true
+}
+
+func (c *Controller) commitT9() {
+	word := c.predictor.Commit()
+	if !c.preediting {
+		return
+	}
+	c.preediting = false
+	if c.out.IsCapsLock() {
+		word = strings.ToUpper(word)
+	}
+	ime := c.IME
+	c.queue.Do(func() {
+		ime.HidePreedit()
+		ime.HideCandidates()
+		if word != "" {
+			ime.CommitText(word)
+		}
+	})
+}
+
+func (c *Controller) toggleCapsLock() {
+	c.out.ToggleCapsLock()
+	if c.out.IsCapsLock() {
+		c.notify("uppercase")
+	} else {
+		c.notify("lowercase")
+	}
+}
+
+func (c *Controller) processT9Input(event *gamepad.Event) {
+	if !event.Pressed {
+		return
+	}
+	word := c.predictor.Word()
+	if key := t9.Key(event); key >= 0 {
+		if c.entry == EntryPredictive {
+			c.predictT9(word, c.predictor.Press(key))
+		} else {
+			c.typeT9(c.multiTap.Press(key, time.Now()))
+		}
+		return
+	}
+
+	if event.IsShoulder(gamepad.ShoulderR) {
+		if c.entry == EntryPredictive {
+			c.predictT9(word, c.predictor.Next())
+		} else {
+			c.multiTap.Commit()
+		}
+		return
+	} else if event.IsButton(gamepad.ButtonL) {
+		if c.entry == EntryPredictive {
+			c.predictT9(word, c.predictor.Backspace())
+		} else {
+			c.out.KeyPress("BackSpace")
+		}
+		return
+	}
+
+	c.commitT9()
+	c.multiTap.Commit()
+	if event.IsButton(gamepad.ButtonR) {
+		c.typeKey("space")
+	} else if event.IsButton(gamepad.ButtonRightStick) {
+		c.typeKey("Return")
+	} else if event.IsButton(gamepad.ButtonStart) {
+		c.toggleCapsLock()
+	} else if event.IsButton(gamepad.ButtonSelect) {
+		c.setMode(ModeMouse)
+		c.notify("mouse")
+	}
+}
+
+func (c *Controller) processChordInput(event *gamepad.Event) {
+	if event.InputType == gamepad.InputButton || event.InputType == gamepad.InputDpad {
+		if ch, ok := c.chordTracker.Update(chord.Chord(c.gpad.State.Chord())); ok {
+			if key, ok := c.chords[ch]; ok {
+				c.debugf("chord: %v -> %v\n", ch, key)
+				c.typeKey(key)
+			} else {
+				c.debugf("chord: %v -> ?\n", ch)
+			}
+		}
+	}
+	if !event.Pressed {
+		return
+	}
+	if event.IsButton(gamepad.ButtonL) {
+		c.typeKey("BackSpace")
+	} else if event.IsButton(gamepad.ButtonR) {
+		c.typeKey("space")
+	} else if event.IsShoulder(gamepad.ShoulderR) {
+		c.typeKey("Return")
+	} else if event.IsButton(gamepad.ButtonStart) {
+		c.Notify("chords", c.chords.Table())
+	} else if event.IsButton(gamepad.ButtonSelect) {
+		c.chordTracker.Reset()
+		c.setMode(ModeMouse)
+		c.notify("mouse")
+	}
+}
+
+func (c *Controller) processBrailleInput(event *gamepad.Event) {
+	if event.IsButton(gamepad.ButtonL) || event.IsButton(gamepad.ButtonR) ||
+		event.IsButton(gamepad.ButtonY) || event.IsButton(gamepad.ButtonA) ||
+		event.InputType == gamepad.InputShoulder {
+		if dots, ok := c.brailleTracker.Update(braille.DotsOf(c.gpad)); ok {
+			edit, ok := c.brailleTranslator.Cell(dots)
+			c.debugf("braille: %v -> %q\n", dots, edit.Text)
+			if ok {
+				c.typeText(edit.Text)
+			}
+		}
+		return
+	}
+	if !event.Pressed {
+		return
+	}
+	if event.IsButton(gamepad.ButtonB) {
+		if edit := c.brailleTranslator.Space(); edit.Erase > 0 {
+			c.corrector.Reset()
+			c.applyEdit(autocorrect.Edit(edit))
+		}
+		c.typeKey("space")
+	} else if event.IsButton(gamepad.ButtonX) {
+		c.brailleTranslator.Backspace()
+		c.typeKey("BackSpace")
+	} else if event.IsDpad(gamepad.DirDown) {
+		c.brailleTranslator.Reset()
+		c.typeKey("Return")
+	} else if event.IsDpad(gamepad.DirLeft) {
+		c.brailleTranslator.Reset()
+		c.typeKey("Left")
+	} else if event.IsDpad(gamepad.DirRight) {
+		c.brailleTranslator.Reset()
+		c.typeKey("Right")
+	} else if event.IsButton(gamepad.ButtonStart) {
+		c.brailleTranslator.Grade2 = !c.brailleTranslator.Grade2
+		if c.brailleTranslator.Grade2 {
+			c.notify("braille grade 2")
+		} else {
+			c.notify("braille grade 1")
+		}
+	} else if event.IsButton(gamepad.ButtonSelect) {
+		c.brailleTracker.Reset()
+		c.setMode(ModeMouse)
+		c.notify("mouse")
+	}
+}
+
+func (c *Controller) typeMorse(text string) {
+	switch text {
+	case morse.Space:
+		c.typeKey("space")
+	case morse.Newline:
+		c.typeKey("Return")
+	case morse.Backspace:
+		c.typeKey("BackSpace")
+	default:
+		if c.out.IsCapsLock() {
+			text = strings.ToUpper(text)
+		}
+		c.typeText(text)
+	}
+}
+
+func (c *Controller) processMorseInput(event *gamepad.Event) {
+	if event.IsButton(gamepad.ButtonB) || event.IsButton(gamepad.ButtonY) || event.IsButton(gamepad.ButtonA) {
+		c.morseDown = event.Pressed
+		if event.Pressed {
+			c.morsePressed = event.Time
+			return
+		}
+		c.morseUp = time.Now()
+		if event.IsButton(gamepad.ButtonY) {
+			c.morseDecoder.Dot()
+		} else if event.IsButton(gamepad.ButtonA) {
+			c.morseDecoder.Dash()
+		} else {
+			d := time.Duration(event.Time-c.morsePressed) * time.Millisecond
+			c.morseDecoder.Key(d)
+		}
+		c.debugf("morse: %v (unit %v)\n", c.morseDecoder.Symbols(), c.morseDecoder.Unit)
+		return
+	}
+	if !event.Pressed {
+		return
+	}
+	if event.IsButton(gamepad.ButtonL) {
+		c.morseDecoder.Reset()
+		c.typeKey("BackSpace")
+	} else if event.IsButton(gamepad.ButtonR) {
+		c.morseDecoder.Reset()
+		c.typeKey("space")
+	} else if event.IsButton(gamepad.ButtonStart) {
+		c.toggleCapsLock()
+	} else if event.IsButton(gamepad.ButtonSelect) {
+		c.morseDecoder.Reset()
+		c.setMode(ModeMouse)
+		c.notify("mouse")
+	}
+}
+
+func (c *Controller) showScanner() {
+	row, col := c.scanner.Highlight()
+	c.Display.Show("scanning", c.scanLabels, row, col)
+}
+
+func (c *Controller) processScanInput(event *gamepad.Event) {
+	if !event.Pressed {
+		return
+	}
+	// a helper can tune the scan speed from the dpad
+	if event.IsDpad(gamepad.DirUp) && c.scanner.Interval > 200*time.Millisecond {
+		c.scanner.Interval -= 100 * time.Millisecond
+		c.notify(fmt.Sprintf("scan interval: %v", c.scanner.Interval))
+		return
+	} else if event.IsDpad(gamepad.DirDown) {
+		c.scanner.Interval += 100 * time.Millisecond
+		c.notify(fmt.Sprintf("scan interval: %v", c.scanner.Interval))
+		return
+	}
+	if !event.IsButton(c.scanButton) {
+		return
+	}
+	if key, ok := c.scanner.Select(time.Now()); ok {
+		c.typeKey(key)
+	}
+	c.showScanner()
+}
+
+func (c *Controller) processKeyTick() {
+	if c.Mode() != ModeKeyb {
+		return
+	}
+	if c.entry == EntryMorse && !c.morseDown {
+		if text, ok := c.morseDecoder.Gap(time.Since(c.morseUp)); ok {
+			c.typeMorse(text)
+		}
+	} else if c.entry == EntryScan {
+		if c.scanner.Tick(time.Now()) {
+			c.showScanner()
+		}
+	}
+}
+
+func (c *Controller) showPicker() {
+	page, item := c.symbols.Page()
+	row := []string{}
+	for _, sym := range page {
+		row = append(row, sym.Char)
+	}
+	c.Display.Show(c.symbols.Title(), hud.Grid{row}, 0, item)
+}
+
+func (c *Controller) closePicker() {
+	c.picking = false
+	c.Display.Hide()
+}
+
+func (c *Controller) pickSymbol() {
+	sym, ok := c.symbols.Pick()
+	c.closePicker()
+	if !ok {
+		return
+	}
+	c.typeText(sym.Char)
+	if err := c.symbols.SaveRecent(picker.RecentPath()); err != nil {
+		fmt.Printf("failed to save recent symbols: %v\n", err)
+	}
+}
+
+func (c *Controller) processPickerInput(event *gamepad.Event) {
+	if !event.Pressed {
+		return
+	}
+	symbols := c.symbols
+	if symbols.Searching() {
+		if event.IsButton(gamepad.ButtonStart) {
+			symbols.EndSearch()
+		} else if event.IsButton(gamepad.ButtonSelect) {
+			c.closePicker()
+			return
+		} else if slot := layout.SlotOf(event); slot >= 0 {
+			layer := c.keyLayout.Layer(layout.Held(c.gpad))
+			if layer == nil {
+				return
+			}
+			switch key := layer.Keys[slot]; key {
+			case "space":
+				symbols.Type(" ")
+			case "BackSpace":
+				symbols.Backspace()
+			case "Return":
+				c.pickSymbol()
+				return
+			default:
+				if len(key) == 1 {
+					symbols.Type(key)
+				}
+			}
+		}
+		c.showPicker()
+		return
+	}
+
+	if event.IsDpad(gamepad.DirLeft) {
+		symbols.Move(-1)
+	} else if event.IsDpad(gamepad.DirRight) {
+		symbols.Move(1)
+	} else if event.IsDpad(gamepad.DirUp) {
+		symbols.NextCategory(-1)
+	} else if event.IsDpad(gamepad.DirDown) {
+		symbols.NextCategory(1)
+	} else if event.IsButton(gamepad.ButtonX) {
+		symbols.Move(picker.PageSize)
+	} else if event.IsButton(gamepad.ButtonY) {
+		symbols.StartSearch()
+	} else if event.IsButton(gamepad.ButtonA) {
+		c.pickSymbol()
+		return
+	} else if event.IsButton(gamepad.ButtonB) || event.IsButton(gamepad.ButtonSelect) {
+		c.closePicker()
+		return
+	}
+	c.showPicker()
+}
+
+func (c *Controller) setEntry(e int) {
+	c.entry = e
+	c.lrPending = nil
+	c.corrector.Reset()
+	c.trace.Reset()
+	c.commitT9()
+	c.multiTap.Commit()
+	c.chordTracker.Reset()
+	c.brailleTracker.Reset()
+	c.brailleTranslator.Reset()
+	c.morseDecoder.Reset()
+	c.picking = false
+	c.scanner.Restart(time.Now())
+	c.Display.Hide()
+}
+
+// lrHeld reports whether L and R are both down, which with a third
+// button makes the combos kept for switching entry methods, languages
+// and the picker.
+func (c *Controller) lrHeld() bool {
+	return c.gpad.IsButtonDown(gamepad.ButtonL) && c.gpad.IsButtonDown(gamepad.ButtonR)
+}
+
+// deferLR holds back L and R presses in the entry methods that type
+// with them, until they're let go or another button is pressed, so the
+// L+R combos don't type anything first. It reports whether the event
+// was held back.
+func (c *Controller) deferLR(event *gamepad.Event) bool {
+	switch c.entry {
+	case EntryDaisy, EntrySwipe, EntryMultiTap, EntryPredictive, EntryChord, EntryMorse:
+	default:
+		return false
+	}
+	if event.IsButton(gamepad.ButtonL) || event.IsButton(gamepad.ButtonR) {
+		if event.Pressed {
+			c.lrPending = append(c.lrPending, event)
+			return true
+		}
+		c.flushLR()
+		return false
+	}
+	if event.Pressed && event.InputType != gamepad.InputAnalogLeft && event.InputType != gamepad.InputAnalogRight {
+		c.flushLR()
+	}
+	return false
+}
+
+// flushLR handles the held back L and R presses, in the order they
+// came.
+func (c *Controller) flushLR() {
+	pending := c.lrPending
+	c.lrPending = nil
+	for _, event := range pending {
+		c.processEntryInput(event)
+	}
+}
+
+// processEntryInput hands the event to the entry method, and reports
+// whether there was one other than the layout.
+func (c *Controller) processEntryInput(event *gamepad.Event) bool {
+	switch c.entry {
+	case EntryDaisy:
+		c.processDaisyInput(event)
+	case EntrySwipe:
+		c.processSwipeInput(event)
+	case EntryMultiTap, EntryPredictive:
+		c.processT9Input(event)
+	case EntryChord:
+		c.processChordInput(event)
+	case EntryBraille:
+		c.processBrailleInput(event)
+	case EntryMorse:
+		c.processMorseInput(event)
+	case EntryScan:
+		c.processScanInput(event)
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *Controller) processKeyInput(event *gamepad.Event) {
+	gpad := c.gpad
+	if event.Pressed && event.IsButton(gamepad.ButtonSelect) && c.lrHeld() {
+		c.setEntry((c.entry + 1) % len(entryNames))
+		c.notify(entryNames[c.entry])
+		return
+	}
+	if event.Pressed && event.IsButton(gamepad.ButtonLeftStick) && c.lrHeld() {
+		c.lrPending = nil
+		c.switchLanguage(c.language + 1)
+		return
+	}
+	if event.Pressed && event.IsButton(gamepad.ButtonStart) && c.lrHeld() {
+		c.lrPending = nil
+		c.picking = true
+		c.symbols.Open()
+		c.showPicker()
+		return
+	}
+	if c.picking {
+		c.processPickerInput(event)
+		return
+	}
+	if c.switching {
+		c.processSwitcherInput(event)
+		return
+	}
+	if c.runBinding(event) {
+		c.lrPending = nil
+		return
+	}
+	if c.deferLR(event) || c.processEntryInput(event) {
+		return
+	}
+
+	if event.IsLeftAnalog(gamepad.DirUp) {
+		c.out.SetShift(event.Pressed)
+	} else if event.IsRightAnalog(gamepad.DirUp) {
+		c.out.SetShift(event.Pressed)
+	}
+
+	if event.IsLeftAnalog(gamepad.DirRight) {
+		c.out.SetCtrl(event.Pressed)
+	}
+
+	if !event.Pressed {
+		return
+	}
+
+	layer := c.keyLayout.Layer(layout.Held(gpad))
+	if slot := layout.SlotOf(event); slot >= 0 {
+		if layer != nil {
+			c.runKey(layer.Keys[slot])
+		}
+	} else if layer == nil || layer.Mods != 0 {
+		return
+	} else if event.IsButton(gamepad.ButtonSelect) {
+		c.setMode(ModeMouse)
+		c.notify("mouse")
+	} else if event.IsButton(gamepad.ButtonStart) {
+		c.toggleCapsLock()
+	} else if event.IsButton(gamepad.ButtonLeftStick) {
+		c.out.ToggleCtrl()
+	} else if event.IsButton(gamepad.ButtonRightStick) {
+		c.out.ToggleAlt()
+	}
+}
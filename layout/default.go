@@ -0,0 +1,108 @@
+package layout
+
+// Default is the english layout. Letters sit on the base, L and R
+// layers, the shoulders hold numbers and editing keys, the right stick
+// selects punctuation, and combinations of the shoulder buttons reach
+// the rest of a full keyboard.
+var Default = &Layout{
+	Name: "english",
+	Layers: []*Layer{
+		{Name: "modifiers", Mods: ModL | ModR | ModSL, Keys: [Slots]string{
+			"Shift_L", "Shift_R", "Control_R", "Control_L",
+			"Alt_L", "asciitilde", "Alt_R", "",
+		}},
+		{Name: "navigation", Mods: ModL | ModR, Keys: [Slots]string{
+			"Home", "Prior", "End", "Next",
+			"Left", "Up", "Right", "Down",
+		}},
+		{Name: "function", Mods: ModL | ModSL, Keys: [Slots]string{
+			"F5", "F6", "F8", "F7",
+			"F1", "F2", "F4", "F3",
+		}},
+		{Name: "function2", Mods: ModL | ModSR, Keys: [Slots]string{
+			"F10", "F9", "F11", "F12",
+			"Escape", "Tab", "Insert", "Menu",
+		}},
+		{Name: "keypad", Mods: ModR | ModSL, Keys: [Slots]string{
+			"KP_5", "KP_6", "KP_8", "KP_7",
+			"KP_1", "KP_2", "KP_4", "KP_3",
+		}},
+		{Name: "keypad2", Mods: ModR | ModSR, Keys: [Slots]string{
+			"KP_0", "KP_9", "KP_Add", "KP_Subtract",
+			"KP_Multiply", "KP_Divide", "KP_Decimal", "KP_Enter",
+		}},
+		{Name: "system", Mods: ModSL | ModSR, Keys: [Slots]string{
+			"Print", "Scroll_Lock", "Pause", "Num_Lock",
+			"Caps_Lock", "Super_L", "ISO_Level3_Shift", "Super_R",
+		}},
+		{Name: "L", Mods: ModL, Keys: [Slots]string{
+			"h", "l", "n", "i",
+			"g", "b", "f", "w",
+		}},
+		{Name: "R", Mods: ModR, Keys: [Slots]string{
+			"y", "p", "m", "u",
+			"q", "z", "x", "v",
+		}},
+		{Name: "numbers", Mods: ModSL, Keys: [Slots]string{
+			"5", "6", "8", "7",
+			"1", "2", "4", "3",
+		}},
+		{Name: "editing", Mods: ModSR, Keys: [Slots]string{
+			"0", "9", "j", "k",
+			"BackSpace", "Delete", "space", "Return",
+		}},
+		{Name: "accents", Mods: ModLeftStickLeft, Keys: [Slots]string{
+			"ä", "ö", "", "å",
+			"", "", "", "",
+		}},
+		{Name: "symbols", Mods: ModLeftStickDown, Keys: [Slots]string{
+			"at", "numbersign", "dollar", "percent",
+			"asciicircum", "ampersand", "asterisk", "bar",
+		}},
+		{Name: "punctuation", Mods: ModRightStickLeft, Keys: [Slots]string{
+			"question", "exclam", "quotedbl", "apostrophe",
+			"period", "comma", ActionAutocorrect, "colon",
+		}},
+		{Name: "punctuation2", Mods: ModRightStickDown, Keys: [Slots]string{
+			"minus", "equal", "slash", "backslash",
+			"bracketleft", "bracketright", "semicolon", "grave",
+		}},
+		{Name: "brackets", Mods: ModRightStickRight, Keys: [Slots]string{
+			"parenleft", "braceleft", "parenright", "braceright",
+			"less", "greater", "plus", "underscore",
+		}},
+		{Name: "base", Keys: [Slots]string{
+			"a", "o", "t", "e",
+			"d", "c", "r", "s",
+		}},
+	},
+}
+
+// StandardKeys are the keysyms of a 105-key ISO keyboard, row by row.
+var StandardKeys = []string{
+	"Escape", "F1", "F2", "F3", "F4", "F5", "F6", "F7", "F8", "F9", "F10", "F11", "F12",
+	"Print", "Scroll_Lock", "Pause",
+
+	"grave", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0", "minus", "equal", "BackSpace",
+	"Insert", "Home", "Prior", "Num_Lock", "KP_Divide", "KP_Multiply", "KP_Subtract",
+
+	"Tab", "q", "w", "e", "r", "t", "y", "u", "i", "o", "p", "bracketleft", "bracketright", "Return",
+	"Delete", "End", "Next", "KP_7", "KP_8", "KP_9", "KP_Add",
+
+	"Caps_Lock", "a", "s", "d", "f", "g", "h", "j", "k", "l", "semicolon", "apostrophe", "backslash",
+	"KP_4", "KP_5", "KP_6",
+
+	"Shift_L", "less", "z", "x", "c", "v", "b", "n", "m", "comma", "period", "slash", "Shift_R",
+	"Up", "KP_1", "KP_2", "KP_3", "KP_Enter",
+
+	"Control_L", "Super_L", "Alt_L", "space", "ISO_Level3_Shift", "Super_R", "Menu", "Control_R",
+	"Left", "Down", "Right", "KP_0", "KP_Decimal",
+}
+
+// ShiftedSymbols are the punctuation keysyms typed with shift on a US
+// keyboard.
+var ShiftedSymbols = []string{
+	"asciitilde", "exclam", "at", "numbersign", "dollar", "percent", "asciicircum",
+	"ampersand", "asterisk", "parenleft", "parenright", "underscore", "plus",
+	"braceleft", "braceright", "bar", "colon", "quotedbl", "greater", "question",
+}
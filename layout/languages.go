@@ -0,0 +1,90 @@
+package layout
+
+// Swedish is for Swedish and Finnish. å, ä and ö take the places of the
+// rare english letters, and the letters are ordered by their frequency
+// in Swedish text.
+var Swedish = derive(Default, "swedish",
+	&Layer{Name: "L", Mods: ModL, Keys: [Slots]string{
+		"d", "o", "m", "k",
+		"g", "v", "h", "f",
+	}},
+	&Layer{Name: "R", Mods: ModR, Keys: [Slots]string{
+		"u", "p", "ä", "b",
+		"c", "å", "ö", "j",
+	}},
+	&Layer{Name: "editing", Mods: ModSR, Keys: [Slots]string{
+		"0", "9", "y", "x",
+		"BackSpace", "Delete", "space", "Return",
+	}},
+	&Layer{Name: "accents", Mods: ModLeftStickLeft, Keys: [Slots]string{
+		"w", "z", "q", "é",
+		"", "", "", "",
+	}},
+	&Layer{Name: "base", Keys: [Slots]string{
+		"e", "a", "n", "t",
+		"r", "s", "i", "l",
+	}},
+)
+
+// German orders the letters by their frequency in German text, with ü,
+// ä, ö and ß in place of the rarest ones.
+var German = derive(Default, "german",
+	&Layer{Name: "L", Mods: ModL, Keys: [Slots]string{
+		"h", "u", "l", "c",
+		"g", "m", "o", "b",
+	}},
+	&Layer{Name: "R", Mods: ModR, Keys: [Slots]string{
+		"w", "f", "k", "z",
+		"p", "v", "ü", "ä",
+	}},
+	&Layer{Name: "editing", Mods: ModSR, Keys: [Slots]string{
+		"0", "9", "ß", "ö",
+		"BackSpace", "Delete", "space", "Return",
+	}},
+	&Layer{Name: "accents", Mods: ModLeftStickLeft, Keys: [Slots]string{
+		"j", "y", "x", "q",
+		"", "", "", "",
+	}},
+	&Layer{Name: "base", Keys: [Slots]string{
+		"e", "n", "i", "s",
+		"r", "a", "t", "d",
+	}},
+)
+
+// Vim is the english layout with a layer for vim in place of the
+// keypad: escape and the command keys on the face buttons, and hjkl on
+// the dpad.
+var Vim = derive(Default, "vim",
+	&Layer{Name: "vim", Mods: ModR | ModSL, Keys: [Slots]string{
+		"Escape", "colon", "slash", "u",
+		"h", "k", "l", "j",
+	}},
+)
+
+// Languages are the layouts that can be switched between.
+var Languages = []*Layout{Default, Swedish, German, Vim}
+
+func ByName(name string) *Layout {
+	for _, l := range Languages {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// derive copies a layout, replacing the layers that have the same
+// modifiers as the given ones.
+func derive(base *Layout, name string, layers ...*Layer) *Layout {
+	l := &Layout{Name: name}
+	for _, layer := range base.Layers {
+		for _, replacement := range layers {
+			if replacement.Mods == layer.Mods {
+				layer = replacement
+				break
+			}
+		}
+		l.Layers = append(l.Layers, layer)
+	}
+	return l
+}
@@ -0,0 +1,191 @@
+package layout
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nvlled/gosn30/gamepad"
+)
+
+// Slots of a layer: the face buttons then the dpad, each listed
+// clockwise from the left.
+const (
+	SlotY = iota
+	SlotX
+	SlotA
+	SlotB
+	SlotLeft
+	SlotUp
+	SlotRight
+	SlotDown
+	Slots
+)
+
+// Modifiers are the held inputs that select a layer.
+type Modifier uint16
+
+const (
+	ModL Modifier = 1 << iota
+	ModR
+	ModSL
+	ModSR
+	ModLeftStickLeft
+	ModLeftStickDown
+	ModRightStickLeft
+	ModRightStickDown
+	ModRightStickRight
+)
+
+// Keys that aren't keysyms but actions handled by the caller.
+const (
+	ActionAutocorrect = "@autocorrect"
+	// ActionSend is followed by a window name and keys, like
+	// "@send:player:space", to send them to that window without
+	// focusing it.
+	ActionSend = "@send:"
+	// ActionLock is followed by a window name, and sends all output to
+	// that window until ActionUnlock.
+	ActionLock   = "@lock:"
+	ActionUnlock = "@unlock"
+
+	// Window actions work on the active window. ActionMove and
+	// ActionResize are followed by pixels, like "@move:-50,0".
+	// ActionDesktop and ActionToDesktop are followed by a desktop
+	// number counting from 1, or by +1 or -1 for the next or previous
+	// desktop.
+	ActionSwitcher  = "@switcher"
+	ActionFocusNext = "@focus-next"
+	ActionFocusPrev = "@focus-prev"
+	ActionMove      = "@move:"
+	ActionResize    = "@resize:"
+	ActionMaximize  = "@maximize"
+	ActionMinimize  = "@minimize"
+	ActionClose     = "@close"
+	ActionDesktop   = "@desktop:"
+	ActionToDesktop = "@to-desktop:"
+)
+
+type Layer struct {
+	Name string
+	Mods Modifier
+	Keys [Slots]string
+}
+
+// Layout is a list of layers. The first layer whose modifiers are all
+// held is used, so layers needing more modifiers go first, and the base
+// layer, which needs none, goes last.
+type Layout struct {
+	Name   string
+	Layers []*Layer
+}
+
+func (l *Layout) Layer(held Modifier) *Layer {
+	for _, layer := range l.Layers {
+		if held&layer.Mods == layer.Mods {
+			return layer
+		}
+	}
+	return nil
+}
+
+func (l *Layout) Find(name string) *Layer {
+	for _, layer := range l.Layers {
+		if layer.Name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+// Held returns the modifiers that are down on the gamepad.
+func Held(gpad *gamepad.GamePad) Modifier {
+	var held Modifier
+	if gpad.IsButtonDown(gamepad.ButtonL) {
+		held |= ModL
+	}
+	if gpad.IsButtonDown(gamepad.ButtonR) {
+		held |= ModR
+	}
+	if gpad.IsShoulderDown(gamepad.ShoulderL) {
+		held |= ModSL
+	}
+	if gpad.IsShoulderDown(gamepad.ShoulderR) {
+		held |= ModSR
+	}
+	if gpad.IsLeftAnalog(gamepad.DirLeft) {
+		held |= ModLeftStickLeft
+	}
+	if gpad.IsLeftAnalog(gamepad.DirDown) {
+		held |= ModLeftStickDown
+	}
+	if gpad.IsRightAnalog(gamepad.DirLeft) {
+		held |= ModRightStickLeft
+	}
+	if gpad.IsRightAnalog(gamepad.DirDown) {
+		held |= ModRightStickDown
+	}
+	if gpad.IsRightAnalog(gamepad.DirRight) {
+		held |= ModRightStickRight
+	}
+	return held
+}
+
+// SlotOf returns the slot of a face button or dpad event, or -1.
+func SlotOf(event *gamepad.Event) int {
+	switch {
+	case event.IsButton(gamepad.ButtonY):
+		return SlotY
+	case event.IsButton(gamepad.ButtonX):
+		return SlotX
+	case event.IsButton(gamepad.ButtonA):
+		return SlotA
+	case event.IsButton(gamepad.ButtonB):
+		return SlotB
+	case event.IsDpad(gamepad.DirLeft):
+		return SlotLeft
+	case event.IsDpad(gamepad.DirUp):
+		return SlotUp
+	case event.IsDpad(gamepad.DirRight):
+		return SlotRight
+	case event.IsDpad(gamepad.DirDown):
+		return SlotDown
+	}
+	return -1
+}
+
+// IsAction reports whether a key is an action rather than a keysym.
+func IsAction(key string) bool {
+	return strings.HasPrefix(key, "@")
+}
+
+// IsText reports whether a key is a character to enter as text rather
+// than a keysym, which is the case for single non-ASCII characters.
+func IsText(key string) bool {
+	r, size := utf8.DecodeRuneInString(key)
+	return size == len(key) && r >= utf8.RuneSelf
+}
+
+// Keys returns every key the layout can produce.
+func (l *Layout) Keys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, layer := range l.Layers {
+		for _, key := range layer.Keys {
+			if key != "" {
+				keys[key] = true
+			}
+		}
+	}
+	return keys
+}
+
+// Missing returns the keys the layout can't produce.
+func (l *Layout) Missing(keys []string) []string {
+	have := l.Keys()
+	var missing []string
+	for _, key := range keys {
+		if !have[key] {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
@@ -1,34 +1,176 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/gen2brain/beeep"
+	"github.com/nvlled/gosn30/autocorrect"
+	"github.com/nvlled/gosn30/config"
 	"github.com/nvlled/gosn30/gamepad"
-	"github.com/nvlled/gosn30/xdo"
+	"github.com/nvlled/gosn30/hud"
+	"github.com/nvlled/gosn30/ibus"
+	"github.com/nvlled/gosn30/inject"
+	"github.com/nvlled/gosn30/layout"
+	"github.com/nvlled/gosn30/x11"
 )
 
-const (
-	ModeKeyb = iota
-	ModeMouse
-)
+var buttonNames = map[string]int{
+	"b":          gamepad.ButtonB,
+	"a":          gamepad.ButtonA,
+	"y":          gamepad.ButtonY,
+	"x":          gamepad.ButtonX,
+	"l":          gamepad.ButtonL,
+	"r":          gamepad.ButtonR,
+	"select":     gamepad.ButtonSelect,
+	"start":      gamepad.ButtonStart,
+	"leftstick":  gamepad.ButtonLeftStick,
+	"rightstick": gamepad.ButtonRightStick,
+}
+
+// buttonByName returns -1 for unknown or empty names, which leaves an
+// action unbound.
+func buttonByName(name string) int {
+	if b, ok := buttonNames[strings.ToLower(name)]; ok {
+		return b
+	}
+	return -1
+}
+
+// binding is a button combo bound to a key or action.
+type binding struct {
+	held   []int
+	button int
+	action string
+}
+
+// parseBindings reads combos like "l+r+y", the last button being the
+// one pressed and the others held.
+func parseBindings(bindings map[string]string) []binding {
+	var parsed []binding
+	for combo, action := range bindings {
+		b := binding{action: action}
+		names := strings.Split(combo, "+")
+		ok := true
+		for i, name := range names {
+			button := buttonByName(strings.TrimSpace(name))
+			if button < 0 {
+				fmt.Printf("unknown button %q in binding %q\n", name, combo)
+				ok = false
+				break
+			}
+			if i == len(names)-1 {
+				b.button = button
+			} else {
+				b.held = append(b.held, button)
+			}
+		}
+		if ok {
+			parsed = append(parsed, b)
+		}
+	}
+	// combos holding more buttons win
+	sort.Slice(parsed, func(i, j int) bool {
+		return len(parsed[i].held) > len(parsed[j].held)
+	})
+	return parsed
+}
+
+// parsePair reads the "x,y" argument of the move and resize actions.
+func parsePair(arg string) (int, int, bool) {
+	parts := strings.Split(arg, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	x, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	y, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	return x, y, err1 == nil && err2 == nil
+}
+
+// desktopArg returns the desktop, counting from 0, that the argument of
+// a desktop action picks: a number counting from 1, or +1 or -1 for
+// the next or previous desktop.
+func desktopArg(arg string, current, count int) (int, bool) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || count == 0 {
+		return 0, false
+	}
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		return ((current+n)%count + count) % count, true
+	}
+	if n < 1 || n > count {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// watchWindows sends the profile the rules pick whenever the active
+// window changes to one that needs another profile. Base is the profile
+// used when no rule matches.
+func watchWindows(conn *x11.Conn, cfg *config.Config, base string, profiles chan<- string) {
+	var last x11.Window
+	current := base
+	for {
+		time.Sleep(500 * time.Millisecond)
+		// errors come from windows closing while they're read, so the
+		// next look will do
+		w, err := conn.ActiveWindow()
+		if err != nil || w == last {
+			continue
+		}
+		last = w
+		name, ok := cfg.Match(w.Instance, w.Class, w.Title, w.Fullscreen)
+		if !ok {
+			name = base
+		}
+		if name != current {
+			current = name
+			profiles <- name
+		}
+	}
+}
 
-func abs16(x int16) int16 {
-	if x < 0 {
-		return -x
+// scrollClicks scrolls by whole notches with the wheel buttons.
+func scrollClicks(out inject.Injector, dx, dy int) {
+	for ; dx < 0; dx++ {
+		out.MouseClick(inject.MbWheelLeft)
+	}
+	for ; dx > 0; dx-- {
+		out.MouseClick(inject.MbWheelRight)
+	}
+	for ; dy < 0; dy++ {
+		out.MouseClick(inject.MbWheelUp)
+	}
+	for ; dy > 0; dy-- {
+		out.MouseClick(inject.MbWheelDown)
 	}
-	return x
 }
 
-func mapValueRange(x int16, srcMin, srcMax, tgtMin, tgtMax float32) int {
-	n := (float32(x) - srcMin) / (srcMax - srcMin)
-	return int((tgtMax-tgtMin)*n + tgtMin)
+func layoutAdjacency(l *layout.Layout) autocorrect.Adjacency {
+	adj := autocorrect.Adjacency{}
+	for _, layer := range l.Layers {
+		adj.LinkRing(layer.Keys[layout.SlotY:layout.SlotLeft]...)
+		adj.LinkRing(layer.Keys[layout.SlotLeft:]...)
+	}
+	// a missed or wrong L/R turns a letter into the one on the same button
+	base, left, right := l.Find("base"), l.Find("L"), l.Find("R")
+	if base != nil && left != nil && right != nil {
+		for i := range base.Keys {
+			adj.LinkRing(base.Keys[i], left.Keys[i], right.Keys[i])
+		}
+	}
+	return adj
 }
 
-func handleLockFile() {
+// handleLockFile makes sure only one instance runs, and on interrupt or
+// termination runs cleanup, removes the lock file and exits.
+func handleLockFile(cleanup func()) {
 	lockFilename := ".gosn30-lock"
 	lockPath := os.Getenv("HOME") + "/" + lockFilename
 
@@ -43,296 +185,115 @@ func handleLockFile() {
 	}
 
 	killSignal := make(chan os.Signal, 1)
-	signal.Notify(killSignal, os.Interrupt)
+	signal.Notify(killSignal, os.Interrupt, syscall.SIGTERM)
 	<-killSignal
+	cleanup()
 	println("removing lockfile")
 	os.Remove(lockPath)
 	os.Exit(0)
 }
 
-func main() {
-	for {
-		mode := ModeKeyb
-		gpad := gamepad.New()
-		xd := xdo.New()
-
-		go handleLockFile()
-		go gpad.StartLoop()
-
-		processKeyInput := func(event *gamepad.Event) {
-			// TODO:
-			//fmt.Printf("pressed: %v, type=%v, dir=%v | %v, %v\n", event.Pressed, event.InputType, event.InputValue, gamepad.InputAnalogLeft, gamepad.DirUp)
-			if event.IsLeftAnalog(gamepad.DirUp) {
-				xd.SetShift(event.Pressed)
-			} else if event.IsRightAnalog(gamepad.DirUp) {
-				xd.SetShift(event.Pressed)
-			}
-
-			if event.IsLeftAnalog(gamepad.DirRight) {
-				fmt.Printf(">ctrl: %v\n", event.Pressed)
-				xd.SetCtrl(event.Pressed)
-			}
+func printCoverage(l *layout.Layout) {
+	fmt.Printf("layout %v\n", l.Name)
+	missing := l.Missing(layout.StandardKeys)
+	fmt.Printf("standard keys: %v/%v\n", len(layout.StandardKeys)-len(missing), len(layout.StandardKeys))
+	for _, key := range missing {
+		fmt.Printf("  missing %v\n", key)
+	}
+	missing = l.Missing(layout.ShiftedSymbols)
+	fmt.Printf("shifted symbols: %v/%v\n", len(layout.ShiftedSymbols)-len(missing), len(layout.ShiftedSymbols))
+	for _, key := range missing {
+		fmt.Printf("  missing %v\n", key)
+	}
+}
 
-			if !event.Pressed {
-				return
-			}
-			println("X")
+// listWindows prints the windows the window manager knows of, to help
+// pick out the ones to send keys to.
+func listWindows() {
+	conn, err := x11.Dial()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	windows, err := conn.Windows()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for _, w := range windows {
+		fmt.Printf("%v\tpid %v\tclass %q\t%q\n", w.ID, w.PID, w.Class, w.Title)
+	}
+}
 
-			if gpad.IsButtonDown(gamepad.ButtonL) && gpad.IsButtonDown(gamepad.ButtonR) {
-				if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("Left")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("Up")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("Down")
-				} else if event.IsDpad(gamepad.DirRight) {
-					xd.KeyPress("Right")
-				}
-			} else if gpad.IsButtonDown(gamepad.ButtonL) {
-				if event.IsButton(gamepad.ButtonY) {
-					xd.KeyPress("h")
-				} else if event.IsButton(gamepad.ButtonX) {
-					xd.KeyPress("l")
-				} else if event.IsButton(gamepad.ButtonB) {
-					xd.KeyPress("i")
-				} else if event.IsButton(gamepad.ButtonA) {
-					xd.KeyPress("n")
-				} else if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("g")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("b")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("w")
-				} else if event.IsDpad(gamepad.DirRight) {
-					xd.KeyPress("f")
-				}
-			} else if gpad.IsButtonDown(gamepad.ButtonR) {
-				if event.IsButton(gamepad.ButtonY) {
-					xd.KeyPress("y")
-				} else if event.IsButton(gamepad.ButtonX) {
-					xd.KeyPress("p")
-				} else if event.IsButton(gamepad.ButtonB) {
-					xd.KeyPress("u")
-				} else if event.IsButton(gamepad.ButtonA) {
-					xd.KeyPress("m")
-				} else if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("q")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("z")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("v")
-				} else if event.IsDpad(gamepad.DirRight) {
-					xd.KeyPress("x")
-				}
-			} else if gpad.IsShoulderDown(gamepad.ShoulderL) {
-				if event.IsButton(gamepad.ButtonY) {
-					xd.KeyPress("5")
-				} else if event.IsButton(gamepad.ButtonX) {
-					xd.KeyPress("6")
-				} else if event.IsButton(gamepad.ButtonB) {
-					xd.KeyPress("7")
-				} else if event.IsButton(gamepad.ButtonA) {
-					xd.KeyPress("8")
-				} else if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("1")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("2")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("3")
-				} else if event.IsDpad(gamepad.DirRight) {
-					xd.KeyPress("4")
-				}
-			} else if gpad.IsShoulderDown(gamepad.ShoulderR) {
-				if event.IsButton(gamepad.ButtonY) {
-					xd.KeyPress("0")
-				} else if event.IsButton(gamepad.ButtonX) {
-					xd.KeyPress("9")
-				} else if event.IsButton(gamepad.ButtonB) {
-					xd.KeyPress("k")
-				} else if event.IsButton(gamepad.ButtonA) {
-					xd.KeyPress("j")
-				} else if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("BackSpace")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("Delete")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("Return")
-				} else if event.IsDpad(gamepad.DirRight) {
-					xd.KeyPress("space")
-				}
-			} else if gpad.IsLeftAnalog(gamepad.DirLeft) {
-				if event.IsButton(gamepad.ButtonY) {
-					xd.EnterText("ä")
-				} else if event.IsButton(gamepad.ButtonX) {
-					xd.EnterText("ö")
-				} else if event.IsButton(gamepad.ButtonB) {
-					xd.EnterText("å")
-				} else if event.IsButton(gamepad.ButtonA) {
-				}
-			} else if gpad.IsRightAnalog(gamepad.DirLeft) {
-				if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("period")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("comma")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("colon")
-				} else if event.IsDpad(gamepad.DirRight) {
-				}
-			} else {
-				if event.IsButton(gamepad.ButtonY) {
-					xd.KeyPress("a")
-				} else if event.IsButton(gamepad.ButtonX) {
-					xd.KeyPress("o")
-				} else if event.IsButton(gamepad.ButtonB) {
-					xd.KeyPress("e")
-				} else if event.IsButton(gamepad.ButtonA) {
-					xd.KeyPress("t")
-				} else if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("d")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("c")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("s")
-				} else if event.IsDpad(gamepad.DirRight) {
-					xd.KeyPress("r")
-				} else if event.IsButton(gamepad.ButtonSelect) {
-					mode = ModeMouse
-					beeep.Notify("mouse", "", "")
-				} else if event.IsButton(gamepad.ButtonStart) {
-					xd.ToggleCapsLock()
-					if xd.IsCapsLock() {
-						beeep.Notify("uppercase", "", "")
-					} else {
-						beeep.Notify("lowercase", "", "")
-					}
-				} else if event.IsButton(gamepad.ButtonLeftStick) {
-					xd.ToggleCtrl()
-				} else if event.IsButton(gamepad.ButtonRightStick) {
-					xd.ToggleAlt()
-				}
-			}
+func main() {
+	coverage := flag.Bool("coverage", false, "list the keys the layout can't type, then exit")
+	windows := flag.Bool("windows", false, "list the open windows, then exit")
+	dryRun := flag.Bool("dry-run", false, "print the keys and pointer events instead of sending them")
+	grab := flag.Bool("grab", false, "keep other programs from seeing the gamepad, except in passthrough")
+	verbose := flag.Bool("verbose", false, "print what the text entry methods make of the input")
+	flag.Parse()
+	if *coverage {
+		for _, l := range layout.Languages {
+			printCoverage(l)
 		}
+		return
+	}
+	if *windows {
+		listWindows()
+		return
+	}
 
-		processMouseInput := func(event *gamepad.Event) {
-			if event.IsButton(gamepad.ButtonA) {
-				xd.MousePress(xdo.MbLeft, event.Pressed)
-			} else if event.IsButton(gamepad.ButtonB) {
-				xd.MousePress(xdo.MbRight, event.Pressed)
-			} else if event.Pressed {
-				if event.IsButton(gamepad.ButtonSelect) {
-					mode = ModeKeyb
-					beeep.Notify("keyboard", "", "")
-				} else if gpad.IsShoulderDown(gamepad.ShoulderL) && gpad.IsRightAnalog(gamepad.DirLeft) {
-					xd.KeyPress("Alt_L+Left")
-				} else if gpad.IsShoulderDown(gamepad.ShoulderL) && gpad.IsRightAnalog(gamepad.DirRight) {
-					xd.KeyPress("Alt_L+Right")
-				} else if event.IsDpad(gamepad.DirLeft) {
-					xd.KeyPress("Left")
-				} else if event.IsDpad(gamepad.DirUp) {
-					xd.KeyPress("Up")
-				} else if event.IsDpad(gamepad.DirDown) {
-					xd.KeyPress("Down")
-				} else if event.IsDpad(gamepad.DirRight) {
-					xd.KeyPress("Right")
-				}
-			}
+	for {
+		cfg, err := config.Load(config.Path())
+		if err != nil {
+			fmt.Printf("failed to load config: %v\n", err)
+		}
+		baseProfile := os.Getenv("GOSN30_PROFILE")
+		if _, ok := cfg.Profiles[baseProfile]; !ok {
+			baseProfile = config.DefaultProfile
 		}
 
-		keyChan := make(chan *gamepad.Event)
-		gpad.Poll(func(event *gamepad.Event) {
-			if mode == ModeMouse {
-				processMouseInput(event)
-			} else {
-				keyChan <- event
+		gpad := gamepad.New()
+		var xd inject.Injector = &inject.Recorder{Out: os.Stdout}
+		if !*dryRun {
+			var backend string
+			if xd, backend, err = inject.Open(os.Getenv("GOSN30_BACKEND")); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
 			}
-		})
-		go func() {
-			for {
-				processKeyInput(<-keyChan)
+			fmt.Printf("output: %v\n", backend)
+		}
+		c := NewController(xd, gpad, cfg, baseProfile)
+		c.Verbose = *verbose
+		if e, ok := entryByName(os.Getenv("GOSN30_ENTRY")); ok {
+			c.setEntry(e)
+		}
+		if os.Getenv("GOSN30_OUTPUT") == "ibus" && !*dryRun {
+			if c.IME, err = ibus.Connect(ibus.Address()); err != nil {
+				fmt.Printf("failed to register the IBus engine: %v\n", err)
+				c.IME = nil
 			}
-		}()
-		/*
-			go func() {
-				buttonDown := false
-				downTime := time.Now().UnixNano()
-				micros := int64(1000 * 1000)
-				for {
-					time.Sleep(100 * time.Millisecond)
-					lastEvent := gpad.LastEvent
-
-					if mode != ModeKeyb || lastEvent == nil {
-						continue
-					}
-
-					eventVal := lastEvent.InputValue
-					if eventVal == gamepad.ButtonL ||
-						eventVal == gamepad.ButtonR {
-						continue
-					}
-					if lastEvent.InputType != gamepad.InputDpad && lastEvent.InputType != gamepad.InputButton {
-						continue
-					}
-
-					if !lastEvent.Pressed {
-						buttonDown = false
-						continue
-					}
-
-					if !buttonDown && lastEvent.Pressed {
-						downTime = time.Now().UnixNano()
-						buttonDown = true
-					} else {
-						buttonDown = lastEvent.Pressed
-						now := time.Now().UnixNano()
-						if buttonDown {
-							if (now-downTime)/micros > 1200 {
-								//fmt.Printf("button down elapsed: %v\n", (now-downTime)/micros)
-								keyChan <- lastEvent
-							}
-						}
-					}
-
-				}
-			}()
-		*/
-
-		lastScroll := time.Now().UnixNano()
-		for {
-
-			if mode == ModeMouse {
-				var maxSpeed float32 = 20.0
-				if gpad.IsButtonDown(gamepad.ButtonR) {
-					maxSpeed = 7
-				}
-
-				var dx, dy int
-				if gpad.State.LeftStick.X != 0 {
-					dx = mapValueRange(gpad.State.LeftStick.X, -32767, 32767, -maxSpeed, maxSpeed)
-				}
-				if gpad.State.LeftStick.Y != 0 {
-					dy = mapValueRange(gpad.State.LeftStick.Y, -32767, 32767, -maxSpeed, maxSpeed)
-				}
-				if dx != 0 || dy != 0 {
-					xd.MouseMove(dx, dy)
-				}
+		}
+		if desk, err := x11.Dial(); err == nil {
+			c.SetDesktop(desk)
+			c.Display = hud.New(desk)
+		}
 
-				if gpad.State.RightStick.Y != 0 {
-					delay := mapValueRange(abs16(gpad.State.RightStick.Y), 0, 32767, 128, 0)
-					now := time.Now().UnixNano()
-					millis := (now - lastScroll) / 1000000
-					fmt.Printf("delay: %v, diff: %v\n", delay, millis)
-					if millis >= int64(delay) {
-						if gpad.State.RightStick.Y < 0 {
-							xd.MouseClick(xdo.MbWheelUp)
-						} else {
-							xd.MouseClick(xdo.MbWheelDown)
-						}
-						lastScroll = now
-					}
+		go handleLockFile(func() {
+			if c.IME != nil {
+				if err := c.IME.Close(); err != nil {
+					fmt.Printf("failed to restore the previous input method: %v\n", err)
 				}
 			}
-			time.Sleep(20 * time.Millisecond)
-		}
+		})
+		gpad.Exclusive = *grab
+		gpad.SetGrab(c.Mode() != ModePassthrough)
+		go gpad.StartLoop()
 
+		gpad.Poll(c.Poll)
+		gpad.PollMotion(c.PollMotion)
+		c.WatchWindows()
+		c.Run()
 	}
 }
@@ -0,0 +1,72 @@
+// Package modifier keeps track of the modifiers the gamepad holds and
+// toggles, for the outputs to apply to the keys they type.
+package modifier
+
+import (
+	"strings"
+	"unicode"
+)
+
+// State is the modifiers that are on. Shift is held and caps lock is
+// toggled; letters come out in upper case with one of them, but not
+// both. The zero value has nothing on. It isn't safe for concurrent
+// use.
+type State struct {
+	ctrl     bool
+	alt      bool
+	shift    bool
+	capsLock bool
+}
+
+func (s *State) SetCtrl(val bool) {
+	s.ctrl = val
+}
+
+func (s *State) SetShift(val bool) {
+	s.shift = val
+}
+
+func (s *State) ToggleCapsLock() {
+	s.capsLock = !s.capsLock
+}
+
+func (s *State) ToggleCtrl() {
+	s.ctrl = !s.ctrl
+}
+
+func (s *State) ToggleAlt() {
+	s.alt = !s.alt
+}
+
+// IsCapsLock reports whether caps lock is on, whether or not shift is
+// held.
+func (s *State) IsCapsLock() bool {
+	return s.capsLock
+}
+
+// HasModifiers reports whether ctrl or alt is on.
+func (s *State) HasModifiers() bool {
+	return s.ctrl || s.alt
+}
+
+// Apply returns a key sequence, like "c" or "Control_L+c", with the
+// modifiers that are on added to it.
+func (s *State) Apply(keyseq string) string {
+	if s.shift != s.capsLock && isLetter(keyseq) {
+		keyseq = strings.ToUpper(keyseq)
+	}
+	if s.ctrl {
+		keyseq = "Control_L+" + keyseq
+	}
+	if s.alt {
+		keyseq = "Alt_L+" + keyseq
+	}
+	return keyseq
+}
+
+func isLetter(s string) bool {
+	if len(s) != 1 {
+		return false
+	}
+	return unicode.IsLetter(rune(s[0]))
+}
@@ -0,0 +1,32 @@
+package modifier
+
+import "testing"
+
+func TestApply(t *testing.T) {
+	var s State
+	steps := []struct {
+		change func()
+		key    string
+		want   string
+	}{
+		{func() {}, "a", "a"},
+		{func() { s.SetShift(true) }, "a", "A"},
+		{func() { s.ToggleCapsLock() }, "a", "a"},
+		{func() { s.SetShift(false) }, "a", "A"},
+		{func() {}, "Return", "Return"},
+		{func() { s.ToggleCtrl() }, "a", "Control_L+A"},
+		{func() { s.ToggleAlt(); s.ToggleCapsLock() }, "a", "Alt_L+Control_L+a"},
+	}
+	for i, step := range steps {
+		step.change()
+		if got := s.Apply(step.key); got != step.want {
+			t.Errorf("step %v: %q became %q, want %q", i, step.key, got, step.want)
+		}
+	}
+	if s.IsCapsLock() {
+		t.Error("caps lock still on after toggling it twice")
+	}
+	if !s.HasModifiers() {
+		t.Error("no modifiers with ctrl and alt on")
+	}
+}
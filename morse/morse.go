@@ -0,0 +1,122 @@
+package morse
+
+import (
+	"time"
+)
+
+// Text produced for prosigns that aren't printable.
+const (
+	Backspace = "\b"
+	Newline   = "\n"
+	Space     = " "
+)
+
+var Code = map[string]string{
+	".-": "a", "-...": "b", "-.-.": "c", "-..": "d", ".": "e",
+	"..-.": "f", "--.": "g", "....": "h", "..": "i", ".---": "j",
+	"-.-": "k", ".-..": "l", "--": "m", "-.": "n", "---": "o",
+	".--.": "p", "--.-": "q", ".-.": "r", "...": "s", "-": "t",
+	"..-": "u", "...-": "v", ".--": "w", "-..-": "x", "-.--": "y",
+	"--..": "z",
+
+	".----": "1", "..---": "2", "...--": "3", "....-": "4", ".....": "5",
+	"-....": "6", "--...": "7", "---..": "8", "----.": "9", "-----": "0",
+
+	".-.-.-": ".", "--..--": ",", "..--..": "?", ".----.": "'", "-.-.--": "!",
+	"-..-.": "/", "-.--.": "(", "-.--.-": ")", ".-...": "&", "---...": ":",
+	"-.-.-.": ";", "-...-": "=", ".-.-.": "+", "-....-": "-", "..--.-": "_",
+	".-..-.": "\"", ".--.-.": "@",
+
+	// prosigns: AA (new line) and the error sign
+	".-.-":     Newline,
+	"........": Backspace,
+}
+
+// Decoder turns key presses into text. The length of a dot (the unit)
+// adapts to the sender: a press shorter than two units is a dot, and
+// the unit follows the measured dots and dashes.
+type Decoder struct {
+	Unit    time.Duration
+	MinUnit time.Duration
+	MaxUnit time.Duration
+
+	symbols    []byte
+	letterDone bool
+	wordDone   bool
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{
+		Unit:       120 * time.Millisecond,
+		MinUnit:    40 * time.Millisecond,
+		MaxUnit:    400 * time.Millisecond,
+		letterDone: true,
+		wordDone:   true,
+	}
+}
+
+// Key classifies a press of a straight key by its duration.
+func (dec *Decoder) Key(d time.Duration) byte {
+	if d < 2*dec.Unit {
+		dec.adapt(d)
+		dec.Dot()
+		return '.'
+	}
+	dec.adapt(d / 3)
+	dec.Dash()
+	return '-'
+}
+
+func (dec *Decoder) Dot() {
+	dec.add('.')
+}
+
+func (dec *Decoder) Dash() {
+	dec.add('-')
+}
+
+func (dec *Decoder) add(symbol byte) {
+	dec.symbols = append(dec.symbols, symbol)
+	dec.letterDone = false
+	dec.wordDone = false
+}
+
+func (dec *Decoder) adapt(unit time.Duration) {
+	dec.Unit += (unit - dec.Unit) / 4
+	if dec.Unit < dec.MinUnit {
+		dec.Unit = dec.MinUnit
+	} else if dec.Unit > dec.MaxUnit {
+		dec.Unit = dec.MaxUnit
+	}
+}
+
+// Symbols returns the dots and dashes of the letter being keyed.
+func (dec *Decoder) Symbols() string {
+	return string(dec.symbols)
+}
+
+// Gap is called with the time since the key was last released. A gap
+// of three units ends the letter, and seven units end the word. Each is
+// reported once.
+func (dec *Decoder) Gap(d time.Duration) (string, bool) {
+	if !dec.letterDone && d >= 3*dec.Unit {
+		dec.letterDone = true
+		text, ok := Code[string(dec.symbols)]
+		dec.symbols = dec.symbols[:0]
+		if ok && (text == Newline || text == Backspace) {
+			dec.wordDone = true
+		}
+		return text, ok
+	}
+	if dec.letterDone && !dec.wordDone && d >= 7*dec.Unit {
+		dec.wordDone = true
+		return Space, true
+	}
+	return "", false
+}
+
+func (dec *Decoder) Reset() {
+	dec.symbols = dec.symbols[:0]
+	dec.letterDone = true
+	dec.wordDone = true
+}
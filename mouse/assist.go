@@ -0,0 +1,149 @@
+package mouse
+
+import (
+	"sync"
+	"time"
+)
+
+// Clicker is the output the click assist drives.
+type Clicker interface {
+	MouseDown(button int)
+	MouseUp(button int)
+	MouseClick(button int)
+}
+
+// Assist adds accessibility options to the mouse buttons: dwell
+// clicking, a drag lock, and a delay before presses take effect. It's
+// safe to use from the input handler and the pointer loop at once.
+type Assist struct {
+	// DwellClick clicks DwellButton after the pointer rests this long.
+	DwellClick  time.Duration
+	DwellButton int
+	// ClickConfirm is how long a button must be held before it's
+	// pressed, so brushing against it does nothing.
+	ClickConfirm time.Duration
+
+	out Clicker
+	mu  sync.Mutex
+
+	dragButton int
+	dragLocked bool
+	pending    map[int]time.Time
+	down       map[int]bool
+	lastMove   time.Time
+	dwellArmed bool
+}
+
+func NewAssist(out Clicker, dragButton int) *Assist {
+	return &Assist{
+		DwellButton: dragButton,
+		out:         out,
+		dragButton:  dragButton,
+		pending:     make(map[int]time.Time),
+		down:        make(map[int]bool),
+	}
+}
+
+// Press handles a click button being pressed or released.
+func (a *Assist) Press(button int, pressed bool, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if pressed {
+		a.dwellArmed = false
+		if a.down[button] {
+			return
+		}
+		if a.ClickConfirm > 0 {
+			a.pending[button] = now
+			return
+		}
+		a.down[button] = true
+		a.out.MouseDown(button)
+		return
+	}
+	delete(a.pending, button)
+	if a.down[button] {
+		if button == a.dragButton && a.isDragLocked() {
+			return
+		}
+		a.down[button] = false
+		a.out.MouseUp(button)
+	}
+}
+
+// Click clicks a button count times, for double and triple clicks.
+func (a *Assist) Click(button, count int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := 0; i < count; i++ {
+		a.out.MouseClick(button)
+	}
+	a.dwellArmed = false
+}
+
+// ToggleDragLock holds the drag button down until it's toggled again.
+func (a *Assist) ToggleDragLock() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.isDragLocked() {
+		a.down[a.dragButton] = false
+		a.dragLocked = false
+		a.out.MouseUp(a.dragButton)
+		return false
+	}
+	a.down[a.dragButton] = true
+	a.dragLocked = true
+	a.out.MouseDown(a.dragButton)
+	return true
+}
+
+func (a *Assist) isDragLocked() bool {
+	if !a.down[a.dragButton] {
+		a.dragLocked = false
+	}
+	return a.dragLocked
+}
+
+// Moved tells the assist the pointer moved, which arms the dwell click.
+func (a *Assist) Moved(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastMove = now
+	a.dwellArmed = true
+}
+
+// Tick presses buttons whose confirmation delay is over, and clicks
+// when the pointer has rested long enough.
+func (a *Assist) Tick(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for button, since := range a.pending {
+		if now.Sub(since) >= a.ClickConfirm {
+			delete(a.pending, button)
+			a.down[button] = true
+			a.out.MouseDown(button)
+		}
+	}
+	if a.DwellClick > 0 && a.dwellArmed && !a.isDragLocked() &&
+		now.Sub(a.lastMove) >= a.DwellClick {
+		a.dwellArmed = false
+		a.out.MouseClick(a.DwellButton)
+	}
+}
+
+// Release lets go of every button, including a drag lock.
+func (a *Assist) Release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for button := range a.pending {
+		delete(a.pending, button)
+	}
+	for button, down := range a.down {
+		if down {
+			a.out.MouseUp(button)
+		}
+		a.down[button] = false
+	}
+	a.dragLocked = false
+	a.dwellArmed = false
+}
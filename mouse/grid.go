@@ -0,0 +1,72 @@
+package mouse
+
+// Grid narrows the screen down to a point, like keynav: each step keeps
+// a half or a quarter of the region, and the pointer goes to the middle
+// of what's left. A few steps reach any spot on a large screen.
+type Grid struct {
+	X, Y          int
+	Width, Height int
+
+	history [][4]int
+}
+
+func NewGrid(width, height int) *Grid {
+	return &Grid{Width: width, Height: height}
+}
+
+// Center returns the middle of the region.
+func (g *Grid) Center() (int, int) {
+	return g.X + g.Width/2, g.Y + g.Height/2
+}
+
+func (g *Grid) save() {
+	g.history = append(g.history, [4]int{g.X, g.Y, g.Width, g.Height})
+}
+
+// narrow keeps the half of the region in the direction dx, dy, each -1,
+// 0 or 1, as long as it's more than a pixel wide or high.
+func (g *Grid) narrow(dx, dy int) {
+	if dx != 0 && g.Width > 1 {
+		half := g.Width / 2
+		if dx > 0 {
+			g.X += half
+			g.Width -= half
+		} else {
+			g.Width = half
+		}
+	}
+	if dy != 0 && g.Height > 1 {
+		half := g.Height / 2
+		if dy > 0 {
+			g.Y += half
+			g.Height -= half
+		} else {
+			g.Height = half
+		}
+	}
+}
+
+// Half keeps the half of the region in the direction dx, dy, like -1, 0
+// for the left half.
+func (g *Grid) Half(dx, dy int) {
+	g.save()
+	g.narrow(dx, dy)
+}
+
+// Quadrant keeps a quarter of the region, col and row being 0 or 1.
+func (g *Grid) Quadrant(col, row int) {
+	g.save()
+	g.narrow(col*2-1, row*2-1)
+}
+
+// Undo goes back a step, and reports whether there was one.
+func (g *Grid) Undo() bool {
+	n := len(g.history)
+	if n == 0 {
+		return false
+	}
+	r := g.history[n-1]
+	g.history = g.history[:n-1]
+	g.X, g.Y, g.Width, g.Height = r[0], r[1], r[2], r[3]
+	return true
+}
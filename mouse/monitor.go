@@ -0,0 +1,84 @@
+package mouse
+
+import (
+	"sort"
+)
+
+// Monitor is the part of the screen one monitor shows.
+type Monitor struct {
+	Name          string
+	X, Y          int
+	Width, Height int
+	Primary       bool
+}
+
+// Center returns the middle of the monitor.
+func (m Monitor) Center() (int, int) {
+	return m.X + m.Width/2, m.Y + m.Height/2
+}
+
+func (m Monitor) Contains(x, y int) bool {
+	return x >= m.X && x < m.X+m.Width && y >= m.Y && y < m.Y+m.Height
+}
+
+// Clamp returns the point on the monitor nearest to x, y.
+func (m Monitor) Clamp(x, y int) (int, int) {
+	return clamp(x, m.X, m.X+m.Width-1), clamp(y, m.Y, m.Y+m.Height-1)
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Monitors is a monitor layout.
+type Monitors []Monitor
+
+// Sort orders the monitors left to right, then top to bottom, the way
+// they're cycled through.
+func (ms Monitors) Sort() {
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].X != ms[j].X {
+			return ms[i].X < ms[j].X
+		}
+		return ms[i].Y < ms[j].Y
+	})
+}
+
+// At returns the index of the monitor at x, y, or of the nearest one
+// if the point falls in a gap between them. It's -1 if there are no
+// monitors.
+func (ms Monitors) At(x, y int) int {
+	best, dist := -1, 0
+	for i, m := range ms {
+		if m.Contains(x, y) {
+			return i
+		}
+		cx, cy := m.Clamp(x, y)
+		if d := (cx-x)*(cx-x) + (cy-y)*(cy-y); best < 0 || d < dist {
+			best, dist = i, d
+		}
+	}
+	return best
+}
+
+// Scale returns how much faster the pointer should go on monitor i than
+// on the primary one, so it crosses any monitor in about the same time.
+func (ms Monitors) Scale(i int) float64 {
+	primary := ms[0]
+	for _, m := range ms {
+		if m.Primary {
+			primary = m
+			break
+		}
+	}
+	if primary.Height == 0 || ms[i].Height == 0 {
+		return 1
+	}
+	return float64(ms[i].Height) / float64(primary.Height)
+}
@@ -0,0 +1,106 @@
+package mouse
+
+import (
+	"math"
+	"time"
+)
+
+// Acceleration curves, mapping stick deflection to pointer speed.
+const (
+	// CurveLinear makes speed proportional to deflection.
+	CurveLinear = "linear"
+	// CurvePower raises deflection to Exponent, for finer control
+	// near the center.
+	CurvePower = "power"
+	// CurveRamp starts at SlowSpeed and speeds up to MaxSpeed while
+	// the stick stays out of the deadzone.
+	CurveRamp = "ramp"
+)
+
+// Pointer turns stick deflection into pointer motion. The speed is
+// integrated over the time between updates, so it doesn't depend on
+// how often they come, and fractions of a pixel are carried over
+// rather than dropped, so a slight deflection still moves the pointer,
+// just slowly.
+type Pointer struct {
+	Curve string
+	// MaxSpeed is the speed at full deflection, in pixels per second.
+	MaxSpeed float64
+	// Exponent shapes the power curve.
+	Exponent float64
+	// SlowSpeed is where the ramp starts, and RampTime how long it
+	// takes to get to MaxSpeed.
+	SlowSpeed float64
+	RampTime  time.Duration
+	// Deadzone is the deflection, from 0 to 1, that counts as rest.
+	Deadzone float64
+	// Precision is the speed factor at full slow down.
+	Precision float64
+	// Scale multiplies the speed, like for a monitor with more pixels.
+	Scale float64
+
+	fracX  float64
+	fracY  float64
+	last   time.Time
+	moving time.Time
+}
+
+func NewPointer() *Pointer {
+	return &Pointer{
+		Curve:     CurveLinear,
+		MaxSpeed:  1000,
+		Exponent:  2,
+		SlowSpeed: 250,
+		RampTime:  600 * time.Millisecond,
+		Deadzone:  0.08,
+		Precision: 0.35,
+		Scale:     1,
+	}
+}
+
+// speed returns the speed for a deflection past the deadzone, from 0
+// to 1.
+func (p *Pointer) speed(m float64, now time.Time) float64 {
+	switch p.Curve {
+	case CurvePower:
+		return p.MaxSpeed * math.Pow(m, p.Exponent)
+	case CurveRamp:
+		t := 1.0
+		if p.RampTime > 0 {
+			t = math.Min(1, float64(now.Sub(p.moving))/float64(p.RampTime))
+		}
+		return m * (p.SlowSpeed + (p.MaxSpeed-p.SlowSpeed)*t)
+	}
+	return p.MaxSpeed * m
+}
+
+// Update returns how many pixels to move for the stick at (x, y), each
+// from -1 to 1. Slow is how much to slow down, from 0 to 1, like the
+// depth of a trigger.
+func (p *Pointer) Update(x, y, slow float64, now time.Time) (int, int) {
+	dt := 0.0
+	if !p.last.IsZero() {
+		// a stalled loop shouldn't make the pointer jump
+		dt = math.Min(now.Sub(p.last).Seconds(), 0.1)
+	}
+	p.last = now
+
+	mag := math.Hypot(x, y)
+	if mag <= p.Deadzone {
+		p.fracX, p.fracY = 0, 0
+		p.moving = time.Time{}
+		return 0, 0
+	}
+	if p.moving.IsZero() {
+		p.moving = now
+	}
+	m := math.Min(1, (mag-p.Deadzone)/(1-p.Deadzone))
+	speed := p.speed(m, now) * p.Scale * (1 - slow*(1-p.Precision))
+
+	p.fracX += speed * x / mag * dt
+	p.fracY += speed * y / mag * dt
+	dx, dy := math.Trunc(p.fracX), math.Trunc(p.fracY)
+	p.fracX -= dx
+	p.fracY -= dy
+	return int(dx), int(dy)
+}
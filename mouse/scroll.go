@@ -0,0 +1,82 @@
+package mouse
+
+import (
+	"math"
+	"time"
+)
+
+// Scroller turns stick deflection into scrolling, at a speed
+// proportional to how far the stick is pushed. With Momentum set, the
+// scrolling carries on after the stick is let go and slows to a stop.
+type Scroller struct {
+	// Speed is in notches per second at full deflection.
+	Speed    float64
+	Deadzone float64
+	// Momentum is how long kinetic scrolling takes to slow to about a
+	// third of its speed. 0 stops scrolling as soon as the stick is
+	// let go.
+	Momentum time.Duration
+	// Resolution is how many units Update counts per notch, like 1 for
+	// wheel clicks or 120 for high-resolution scrolling.
+	Resolution int
+
+	vx    float64
+	vy    float64
+	fracX float64
+	fracY float64
+	last  time.Time
+}
+
+func NewScroller() *Scroller {
+	return &Scroller{
+		Speed:      25,
+		Deadzone:   0.1,
+		Resolution: 1,
+	}
+}
+
+// Stop ends kinetic scrolling.
+func (s *Scroller) Stop() {
+	s.vx, s.vy = 0, 0
+	s.fracX, s.fracY = 0, 0
+}
+
+// Update returns how far to scroll, in Resolution units per notch,
+// for the stick at (x, y), each from -1 to 1. Right and down are
+// positive.
+func (s *Scroller) Update(x, y float64, now time.Time) (int, int) {
+	dt := 0.0
+	if !s.last.IsZero() {
+		dt = math.Min(now.Sub(s.last).Seconds(), 0.1)
+	}
+	s.last = now
+
+	if math.Hypot(x, y) > s.Deadzone {
+		s.vx = s.axis(x)
+		s.vy = s.axis(y)
+	} else if s.Momentum > 0 {
+		decay := math.Exp(-dt / s.Momentum.Seconds())
+		s.vx *= decay
+		s.vy *= decay
+		if math.Hypot(s.vx, s.vy) < 0.5 {
+			s.Stop()
+		}
+	} else {
+		s.Stop()
+	}
+
+	res := float64(s.Resolution)
+	s.fracX += s.vx * dt * res
+	s.fracY += s.vy * dt * res
+	dx, dy := math.Trunc(s.fracX), math.Trunc(s.fracY)
+	s.fracX -= dx
+	s.fracY -= dy
+	return int(dx), int(dy)
+}
+
+// axis returns the speed along one axis, scaled so the deadzone edge
+// is 0.
+func (s *Scroller) axis(v float64) float64 {
+	m := math.Max(0, (math.Abs(v)-s.Deadzone)/(1-s.Deadzone))
+	return math.Copysign(math.Min(1, m)*s.Speed, v)
+}
@@ -0,0 +1,229 @@
+package picker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PageSize is how many symbols are shown at once.
+const PageSize = 10
+
+const MaxRecent = 20
+
+// Picker browses the symbol categories. The first category holds the
+// recently used symbols, and the last one the search results.
+type Picker struct {
+	Categories []*Category
+
+	recent   *Category
+	results  *Category
+	category int
+	item     int
+	query    string
+	search   bool
+}
+
+func New() *Picker {
+	p := &Picker{
+		recent:  &Category{Name: "recent"},
+		results: &Category{Name: "search"},
+	}
+	p.Categories = append([]*Category{p.recent}, Categories...)
+	p.Categories = append(p.Categories, p.results)
+	return p
+}
+
+func RecentPath() string {
+	return os.Getenv("HOME") + "/.gosn30-recent"
+}
+
+// LoadRecent reads the recently used symbols, one per line.
+func (p *Picker) LoadRecent(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if char := strings.TrimSpace(scanner.Text()); char != "" && len(p.recent.Symbols) < MaxRecent {
+			p.recent.Symbols = append(p.recent.Symbols, p.lookup(char))
+		}
+	}
+	return scanner.Err()
+}
+
+func (p *Picker) SaveRecent(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	for _, sym := range p.recent.Symbols {
+		fmt.Fprintln(file, sym.Char)
+	}
+	return nil
+}
+
+func (p *Picker) lookup(char string) Symbol {
+	for _, c := range Categories {
+		for _, sym := range c.Symbols {
+			if sym.Char == char {
+				return sym
+			}
+		}
+	}
+	return Symbol{Char: char}
+}
+
+// Open starts browsing, at the recent symbols if there are any.
+func (p *Picker) Open() {
+	p.search = false
+	p.item = 0
+	p.category = 0
+	if len(p.recent.Symbols) == 0 {
+		p.category = 1
+	}
+}
+
+func (p *Picker) Category() *Category {
+	return p.Categories[p.category]
+}
+
+func (p *Picker) Selected() (Symbol, bool) {
+	syms := p.Category().Symbols
+	if p.item < 0 || p.item >= len(syms) {
+		return Symbol{}, false
+	}
+	return syms[p.item], true
+}
+
+// Move moves the selection within the category.
+func (p *Picker) Move(step int) {
+	n := len(p.Category().Symbols)
+	if n == 0 {
+		return
+	}
+	p.item = (p.item + step + n) % n
+}
+
+// NextCategory switches category, skipping empty ones.
+func (p *Picker) NextCategory(step int) {
+	n := len(p.Categories)
+	for i := 0; i < n; i++ {
+		p.category = (p.category + step + n) % n
+		if len(p.Category().Symbols) > 0 {
+			break
+		}
+	}
+	p.item = 0
+}
+
+// Pick returns the selected symbol and moves it to the front of the
+// recent symbols.
+func (p *Picker) Pick() (Symbol, bool) {
+	sym, ok := p.Selected()
+	if !ok {
+		return sym, false
+	}
+	recent := []Symbol{sym}
+	for _, s := range p.recent.Symbols {
+		if s.Char != sym.Char && len(recent) < MaxRecent {
+			recent = append(recent, s)
+		}
+	}
+	p.recent.Symbols = recent
+	return sym, true
+}
+
+func (p *Picker) Searching() bool {
+	return p.search
+}
+
+// StartSearch switches to the search results, which are updated as
+// the query is typed.
+func (p *Picker) StartSearch() {
+	p.search = true
+	p.query = ""
+	p.category = len(p.Categories) - 1
+	p.update()
+}
+
+func (p *Picker) EndSearch() {
+	p.search = false
+}
+
+func (p *Picker) Query() string {
+	return p.query
+}
+
+// Type adds a character to the search query.
+func (p *Picker) Type(s string) {
+	p.query += s
+	p.update()
+}
+
+func (p *Picker) Backspace() {
+	if n := len(p.query); n > 0 {
+		p.query = p.query[:n-1]
+		p.update()
+	}
+}
+
+func (p *Picker) update() {
+	p.results.Symbols = Search(p.query)
+	p.item = 0
+}
+
+// Search finds the symbols whose name has every word of the query.
+func Search(query string) []Symbol {
+	words := strings.Fields(strings.ToLower(query))
+	var found []Symbol
+	seen := make(map[string]bool)
+	for _, c := range Categories {
+		for _, sym := range c.Symbols {
+			if seen[sym.Char] || !matches(sym.Name, words) {
+				continue
+			}
+			seen[sym.Char] = true
+			found = append(found, sym)
+		}
+	}
+	return found
+}
+
+func matches(name string, words []string) bool {
+	for _, w := range words {
+		if !strings.Contains(name, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// Page returns the symbols on the selected item's page and the index of
+// the selected item within it.
+func (p *Picker) Page() ([]Symbol, int) {
+	syms := p.Category().Symbols
+	start := p.item / PageSize * PageSize
+	end := start + PageSize
+	if end > len(syms) {
+		end = len(syms)
+	}
+	return syms[start:end], p.item - start
+}
+
+// Title describes the picker state for the HUD.
+func (p *Picker) Title() string {
+	c := p.Category()
+	title := c.Name
+	if p.search || c == p.results {
+		title = fmt.Sprintf("search: %v_", p.query)
+	}
+	if sym, ok := p.Selected(); ok {
+		title = fmt.Sprintf("%v  %v %v [%v/%v]", title, sym.Char, sym.Name, p.item+1, len(c.Symbols))
+	}
+	return title
+}
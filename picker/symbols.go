@@ -0,0 +1,64 @@
+package picker
+
+type Symbol struct {
+	Char string
+	Name string
+}
+
+type Category struct {
+	Name    string
+	Symbols []Symbol
+}
+
+var Categories = []*Category{
+	{Name: "accented", Symbols: []Symbol{
+		{"ä", "a diaeresis"}, {"ö", "o diaeresis"}, {"å", "a ring"}, {"ü", "u diaeresis"},
+		{"ë", "e diaeresis"}, {"ï", "i diaeresis"}, {"ÿ", "y diaeresis"}, {"ß", "sharp s"},
+		{"é", "e acute"}, {"á", "a acute"}, {"í", "i acute"}, {"ó", "o acute"},
+		{"ú", "u acute"}, {"ý", "y acute"}, {"è", "e grave"}, {"à", "a grave"},
+		{"ì", "i grave"}, {"ò", "o grave"}, {"ù", "u grave"}, {"ê", "e circumflex"},
+		{"â", "a circumflex"}, {"î", "i circumflex"}, {"ô", "o circumflex"}, {"û", "u circumflex"},
+		{"ñ", "n tilde"}, {"ã", "a tilde"}, {"õ", "o tilde"}, {"ç", "c cedilla"},
+		{"ø", "o stroke"}, {"æ", "ae ligature"}, {"œ", "oe ligature"}, {"ł", "l stroke"},
+		{"š", "s caron"}, {"ž", "z caron"}, {"č", "c caron"}, {"ř", "r caron"},
+		{"Ä", "capital a diaeresis"}, {"Ö", "capital o diaeresis"}, {"Å", "capital a ring"}, {"Ü", "capital u diaeresis"},
+		{"É", "capital e acute"}, {"Ñ", "capital n tilde"}, {"Ç", "capital c cedilla"}, {"Ø", "capital o stroke"},
+	}},
+	{Name: "math", Symbols: []Symbol{
+		{"±", "plus minus"}, {"×", "multiplication times"}, {"÷", "division"}, {"≠", "not equal"},
+		{"≈", "almost equal approximately"}, {"≤", "less than or equal"}, {"≥", "greater than or equal"}, {"∞", "infinity"},
+		{"√", "square root"}, {"∑", "sum summation sigma"}, {"∏", "product pi"}, {"∫", "integral"},
+		{"∂", "partial differential"}, {"∆", "delta increment"}, {"∇", "nabla"}, {"π", "pi"},
+		{"µ", "micro mu"}, {"°", "degree"}, {"‰", "per mille"}, {"½", "one half"},
+		{"¼", "one quarter"}, {"¾", "three quarters"}, {"²", "superscript two squared"}, {"³", "superscript three cubed"},
+		{"∈", "element of"}, {"∉", "not element of"}, {"⊂", "subset"}, {"∪", "union"},
+		{"∩", "intersection"}, {"∅", "empty set"}, {"∀", "for all"}, {"∃", "there exists"},
+		{"¬", "not negation"}, {"∧", "logical and"}, {"∨", "logical or"}, {"≡", "identical to"},
+	}},
+	{Name: "arrows", Symbols: []Symbol{
+		{"←", "left arrow"}, {"→", "right arrow"}, {"↑", "up arrow"}, {"↓", "down arrow"},
+		{"↔", "left right arrow"}, {"↕", "up down arrow"}, {"↖", "north west arrow"}, {"↗", "north east arrow"},
+		{"↘", "south east arrow"}, {"↙", "south west arrow"}, {"⇐", "left double arrow"}, {"⇒", "right double arrow implies"},
+		{"⇔", "left right double arrow if and only if"}, {"↩", "return arrow"}, {"⟶", "long right arrow"}, {"↻", "clockwise arrow"},
+	}},
+	{Name: "punctuation", Symbols: []Symbol{
+		{"…", "ellipsis"}, {"–", "en dash"}, {"—", "em dash"}, {"•", "bullet"},
+		{"·", "middle dot"}, {"«", "left guillemet quote"}, {"»", "right guillemet quote"}, {"“", "left double quote"},
+		{"”", "right double quote"}, {"‘", "left single quote"}, {"’", "right single quote apostrophe"}, {"¿", "inverted question"},
+		{"¡", "inverted exclamation"}, {"§", "section"}, {"¶", "pilcrow paragraph"}, {"†", "dagger"},
+		{"©", "copyright"}, {"®", "registered"}, {"™", "trade mark"}, {"№", "numero number"},
+		{"€", "euro currency"}, {"£", "pound currency"}, {"¥", "yen currency"}, {"¢", "cent currency"},
+	}},
+	{Name: "emoji", Symbols: []Symbol{
+		{"😀", "grinning face smile"}, {"😂", "tears of joy laugh"}, {"😊", "smiling face blush"}, {"😍", "heart eyes love"},
+		{"😉", "winking face wink"}, {"😎", "sunglasses cool"}, {"🤔", "thinking face"}, {"😅", "sweat smile"},
+		{"😢", "crying face sad"}, {"😭", "loudly crying sob"}, {"😡", "angry face"}, {"😱", "screaming fear"},
+		{"😴", "sleeping face"}, {"🙄", "eye roll"}, {"🙂", "slightly smiling"}, {"🙃", "upside down"},
+		{"👍", "thumbs up yes"}, {"👎", "thumbs down no"}, {"👋", "waving hand hello bye"}, {"👏", "clapping hands"},
+		{"🙏", "folded hands please thanks"}, {"💪", "flexed biceps strong"}, {"👀", "eyes look"}, {"🤷", "shrug"},
+		{"❤️", "red heart love"}, {"💔", "broken heart"}, {"🔥", "fire"}, {"✨", "sparkles"},
+		{"🎉", "party popper celebrate"}, {"💯", "hundred points"}, {"✅", "check mark done"}, {"❌", "cross mark"},
+		{"⭐", "star"}, {"☕", "coffee hot beverage"}, {"🍕", "pizza"}, {"🍺", "beer"},
+		{"🎮", "video game controller"}, {"💻", "laptop computer"}, {"🐛", "bug"}, {"🚀", "rocket launch"},
+	}},
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nvlled/gosn30/gamepad"
+	"github.com/nvlled/gosn30/hud"
+	"github.com/nvlled/gosn30/inject"
+	"github.com/nvlled/gosn30/mouse"
+)
+
+var warpLabels = hud.Grid{{"Y", "X"}, {"B", "A"}}
+
+const (
+	// how long a known pointer position is trusted, since the mouse or
+	// pointer acceleration may have moved it elsewhere
+	pointerRefresh = 200 * time.Millisecond
+	// how close to a monitor edge it's asked for anyway
+	pointerMargin = 16
+)
+
+// queryPointer asks X where the pointer is, once everything queued has
+// gone out.
+func (c *Controller) queryPointer() (int, int, bool) {
+	if c.desk == nil || len(c.monitors) == 0 {
+		return 0, 0, false
+	}
+	c.queue.Flush()
+	x, y, err := c.desk.QueryPointer()
+	if err != nil {
+		c.pointerSeen = time.Time{}
+		return 0, 0, false
+	}
+	c.pointerX, c.pointerY, c.pointerSeen = x, y, time.Now()
+	return x, y, true
+}
+
+// pointerAt returns where the pointer is before moving it by dx, dy.
+// Asking X is a round trip, so a recent answer is used, kept up with
+// our own moves, unless the move could take the pointer near the edge
+// of its monitor.
+func (c *Controller) pointerAt(dx, dy int) (int, int, bool) {
+	if c.pointerSeen.IsZero() || time.Since(c.pointerSeen) > pointerRefresh || len(c.monitors) == 0 {
+		return c.queryPointer()
+	}
+	x, y := c.pointerX, c.pointerY
+	m := c.monitors[c.monitors.At(x, y)]
+	if !m.Contains(x+dx-pointerMargin, y+dy-pointerMargin) || !m.Contains(x+dx+pointerMargin, y+dy+pointerMargin) {
+		return c.queryPointer()
+	}
+	return x, y, true
+}
+
+// pointerMoved keeps the known position up with a move by dx, dy.
+func (c *Controller) pointerMoved(dx, dy int) {
+	c.pointerX += dx
+	c.pointerY += dy
+}
+
+// pointerWarped notes that the pointer was put at x, y.
+func (c *Controller) pointerWarped(x, y int) {
+	if c.desk != nil {
+		c.pointerX, c.pointerY, c.pointerSeen = x, y, time.Now()
+	}
+}
+
+// updateScale sets the pointer speed for the monitor at x, y.
+func (c *Controller) updateScale(x, y int) {
+	c.pointer.Scale = 1
+	if c.monitorSpeed && len(c.monitors) > 0 {
+		c.pointer.Scale = c.monitors.Scale(c.monitors.At(x, y))
+	}
+}
+
+func (c *Controller) showWarp() {
+	x, y := c.warpGrid.Center()
+	c.queue.MouseMoveTo(x, y)
+	c.pointerWarped(x, y)
+	c.Display.Show(fmt.Sprintf("warp %vx%v at %v,%v", c.warpGrid.Width, c.warpGrid.Height, x, y), warpLabels, -1, -1)
+}
+
+func (c *Controller) startWarp() {
+	w, h := c.queue.Screen()
+	if w == 0 || h == 0 {
+		fmt.Println("pointer warping needs the xdo or x11 output")
+		return
+	}
+	c.warpGrid = mouse.NewGrid(w, h)
+	// just the monitor the pointer is on
+	if x, y, ok := c.queryPointer(); ok {
+		m := c.monitors[c.monitors.At(x, y)]
+		c.warpGrid.X, c.warpGrid.Y, c.warpGrid.Width, c.warpGrid.Height = m.X, m.Y, m.Width, m.Height
+	}
+	c.showWarp()
+}
+
+func (c *Controller) endWarp() {
+	c.warpGrid = nil
+	c.Display.Hide()
+}
+
+func (c *Controller) jumpMonitor(step int) {
+	x, y, ok := c.queryPointer()
+	if !ok {
+		fmt.Println("can't tell where the monitors are")
+		return
+	}
+	i := (c.monitors.At(x, y) + step + len(c.monitors)) % len(c.monitors)
+	m := c.monitors[i]
+	cx, cy := m.Center()
+	if c.queue.CanWarp() {
+		c.queue.MouseMoveTo(cx, cy)
+	} else {
+		c.out.MouseMove(cx-x, cy-y)
+	}
+	c.pointerWarped(cx, cy)
+	c.updateScale(cx, cy)
+	c.notify(m.Name)
+}
+
+// movePointer moves the pointer, keeping it on its monitor while
+// confined, and picks up the speed of the monitor it ends up on.
+func (c *Controller) movePointer(dx, dy int) {
+	tracked := c.confined || (c.monitorSpeed && len(c.monitors) > 1)
+	x, y, ok := 0, 0, false
+	if tracked {
+		x, y, ok = c.pointerAt(dx, dy)
+	}
+	if ok && c.confined {
+		tx, ty := c.monitors[c.monitors.At(x, y)].Clamp(x+dx, y+dy)
+		dx, dy = tx-x, ty-y
+	}
+	if dx == 0 && dy == 0 {
+		return
+	}
+	c.out.MouseMove(dx, dy)
+	if !ok {
+		// moved without keeping up, so the position has to be asked
+		c.pointerSeen = time.Time{}
+		return
+	}
+	c.pointerMoved(dx, dy)
+	if c.monitorSpeed {
+		c.updateScale(x+dx, y+dy)
+	}
+}
+
+func (c *Controller) processWarpInput(event *gamepad.Event) {
+	if !event.Pressed {
+		return
+	}
+	grid := c.warpGrid
+	if event.IsDpad(gamepad.DirLeft) {
+		grid.Half(-1, 0)
+	} else if event.IsDpad(gamepad.DirRight) {
+		grid.Half(1, 0)
+	} else if event.IsDpad(gamepad.DirUp) {
+		grid.Half(0, -1)
+	} else if event.IsDpad(gamepad.DirDown) {
+		grid.Half(0, 1)
+	} else if event.IsButton(gamepad.ButtonY) {
+		grid.Quadrant(0, 0)
+	} else if event.IsButton(gamepad.ButtonX) {
+		grid.Quadrant(1, 0)
+	} else if event.IsButton(gamepad.ButtonB) {
+		grid.Quadrant(0, 1)
+	} else if event.IsButton(gamepad.ButtonA) {
+		grid.Quadrant(1, 1)
+	} else if event.IsButton(gamepad.ButtonL) {
+		if !grid.Undo() {
+			return
+		}
+	} else if event.IsButton(gamepad.ButtonR) {
+		c.endWarp()
+		c.assist.Click(inject.MbLeft, 1)
+		return
+	} else if event.IsButton(gamepad.ButtonStart) || event.IsButton(gamepad.ButtonSelect) {
+		c.endWarp()
+		return
+	} else {
+		return
+	}
+	c.showWarp()
+}
+
+func (c *Controller) processMouseInput(event *gamepad.Event) {
+	if c.warpGrid != nil {
+		c.processWarpInput(event)
+		return
+	}
+	if c.switching {
+		c.processSwitcherInput(event)
+		return
+	}
+	if c.runBinding(event) {
+		return
+	}
+	if event.InputType == gamepad.InputButton && event.InputValue >= 0 {
+		if event.InputValue == c.doubleClickButton {
+			if event.Pressed {
+				c.assist.Click(inject.MbLeft, 2)
+			}
+			return
+		} else if event.InputValue == c.tripleClickButton {
+			if event.Pressed {
+				c.assist.Click(inject.MbLeft, 3)
+			}
+			return
+		} else if event.InputValue == c.nextMonitorButton || event.InputValue == c.prevMonitorButton {
+			if event.Pressed {
+				if event.InputValue == c.nextMonitorButton {
+					c.jumpMonitor(1)
+				} else {
+					c.jumpMonitor(-1)
+				}
+			}
+			return
+		} else if event.InputValue == c.confineButton {
+			if event.Pressed {
+				c.confined = !c.confined
+				if c.confined {
+					c.notify("pointer confined")
+				} else {
+					c.notify("pointer free")
+				}
+			}
+			return
+		} else if event.InputValue == c.dragLockButton {
+			if event.Pressed {
+				if c.assist.ToggleDragLock() {
+					c.notify("drag lock")
+				} else {
+					c.notify("drag released")
+				}
+			}
+			return
+		}
+	}
+	gpad := c.gpad
+	if event.IsButton(gamepad.ButtonA) {
+		c.assist.Press(inject.MbLeft, event.Pressed, time.Now())
+	} else if event.IsButton(gamepad.ButtonB) {
+		c.assist.Press(inject.MbRight, event.Pressed, time.Now())
+	} else if event.Pressed {
+		if event.IsButton(gamepad.ButtonStart) {
+			c.startWarp()
+		} else if event.IsButton(gamepad.ButtonSelect) {
+			c.setMode(ModeKeyb)
+			c.notify("keyboard")
+		} else if gpad.IsShoulderDown(gamepad.ShoulderL) && gpad.IsRightAnalog(gamepad.DirLeft) {
+			c.out.KeyPress("Alt_L+Left")
+		} else if gpad.IsShoulderDown(gamepad.ShoulderL) && gpad.IsRightAnalog(gamepad.DirRight) {
+			c.out.KeyPress("Alt_L+Right")
+		} else if event.IsDpad(gamepad.DirLeft) {
+			c.out.KeyPress("Left")
+		} else if event.IsDpad(gamepad.DirUp) {
+			c.out.KeyPress("Up")
+		} else if event.IsDpad(gamepad.DirDown) {
+			c.out.KeyPress("Down")
+		} else if event.IsDpad(gamepad.DirRight) {
+			c.out.KeyPress("Right")
+		}
+	}
+}
+
+// processMouseTick moves the pointer and scrolls by where the sticks
+// are.
+func (c *Controller) processMouseTick(now time.Time) {
+	gpad := c.gpad
+	// R held slows the pointer down fully, the right trigger as far as
+	// it's pulled
+	slow := gpad.State.Trigger(gamepad.ShoulderR)
+	if gpad.IsButtonDown(gamepad.ButtonR) {
+		slow = 1
+	}
+	dx, dy := c.pointer.Update(
+		float64(gpad.State.LeftStick.X)/32767,
+		float64(gpad.State.LeftStick.Y)/32767,
+		slow, now)
+	if dx != 0 || dy != 0 {
+		c.movePointer(dx, dy)
+		c.assist.Moved(now)
+	}
+	c.assist.Tick(now)
+
+	// L2 with the right stick sideways is back and forward
+	rx := float64(gpad.State.RightStick.X) / 32767
+	if gpad.IsShoulderDown(gamepad.ShoulderL) {
+		rx = 0
+	}
+	sx, sy := c.scroller.Update(rx, float64(gpad.State.RightStick.Y)/32767, now)
+	if c.queue.CanScroll() {
+		if sx != 0 || sy != 0 {
+			c.queue.Scroll(sx, sy)
+		}
+	} else {
+		scrollClicks(c.out, sx, sy)
+	}
+}
@@ -0,0 +1,140 @@
+package scan
+
+import (
+	"time"
+)
+
+// DefaultGrid has the most frequent letters in the top rows, so they
+// are reached with the least waiting.
+var DefaultGrid = [][]string{
+	{"space", "e", "t", "a", "o", "i", "n", "BackSpace"},
+	{"s", "h", "r", "d", "l", "u", "c", "Return"},
+	{"m", "w", "f", "g", "y", "p", "b", "period"},
+	{"v", "k", "j", "x", "q", "z", "comma", "apostrophe"},
+	{"1", "2", "3", "4", "5", "6", "7", "8", "9", "0"},
+	{"Left", "Right", "Up", "Down", "Tab", "Escape", "Delete", "question"},
+}
+
+var labels = map[string]string{
+	"space":      "␣",
+	"BackSpace":  "⌫",
+	"Return":     "⏎",
+	"period":     ".",
+	"comma":      ",",
+	"apostrophe": "'",
+	"question":   "?",
+	"Left":       "←",
+	"Right":      "→",
+	"Up":         "↑",
+	"Down":       "↓",
+	"Tab":        "⇥",
+	"Escape":     "Esc",
+	"Delete":     "Del",
+}
+
+// Label returns a short name of a keysym for display.
+func Label(key string) string {
+	if l, ok := labels[key]; ok {
+		return l
+	}
+	return key
+}
+
+func Labels(grid [][]string) [][]string {
+	rows := make([][]string, len(grid))
+	for i, row := range grid {
+		for _, key := range row {
+			rows[i] = append(rows[i], Label(key))
+		}
+	}
+	return rows
+}
+
+// Scanner moves a highlight over the rows of a grid, and once a row is
+// selected, over its columns. Selecting a column gives its key.
+type Scanner struct {
+	Grid [][]string
+	// Interval is how long each row or column stays highlighted.
+	Interval time.Duration
+	// Dwell is added to the first item after the scan starts or a
+	// level changes, so there's time to react.
+	Dwell time.Duration
+	// Loops is how many times the columns are scanned before going
+	// back to the rows.
+	Loops int
+
+	row   int
+	col   int
+	loops int
+	next  time.Time
+}
+
+func New(grid [][]string) *Scanner {
+	return &Scanner{
+		Grid:     grid,
+		Interval: 800 * time.Millisecond,
+		Dwell:    400 * time.Millisecond,
+		Loops:    2,
+		col:      -1,
+	}
+}
+
+// Highlight returns the highlighted row and column. The column is -1
+// while rows are scanned.
+func (s *Scanner) Highlight() (int, int) {
+	return s.row, s.col
+}
+
+// Restart starts scanning the rows from the top.
+func (s *Scanner) Restart(now time.Time) {
+	s.row = 0
+	s.col = -1
+	s.loops = 0
+	s.next = now.Add(s.Interval + s.Dwell)
+}
+
+// Tick advances the highlight when its time is up. It reports whether
+// the highlight moved.
+func (s *Scanner) Tick(now time.Time) bool {
+	if len(s.Grid) == 0 {
+		return false
+	}
+	if s.next.IsZero() {
+		s.Restart(now)
+		return true
+	}
+	if now.Before(s.next) {
+		return false
+	}
+	s.next = now.Add(s.Interval)
+	if s.col < 0 {
+		s.row = (s.row + 1) % len(s.Grid)
+		return true
+	}
+	s.col++
+	if s.col >= len(s.Grid[s.row]) {
+		s.col = 0
+		s.loops++
+		if s.loops >= s.Loops {
+			s.col = -1
+			s.loops = 0
+		}
+	}
+	return true
+}
+
+// Select picks the highlighted row, or the highlighted key.
+func (s *Scanner) Select(now time.Time) (string, bool) {
+	if len(s.Grid) == 0 {
+		return "", false
+	}
+	if s.col < 0 {
+		s.col = 0
+		s.loops = 0
+		s.next = now.Add(s.Interval + s.Dwell)
+		return "", false
+	}
+	key := s.Grid[s.row][s.col]
+	s.Restart(now)
+	return key, true
+}
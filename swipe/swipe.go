@@ -0,0 +1,195 @@
+package swipe
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nvlled/gosn30/dict"
+	"github.com/nvlled/gosn30/gamepad"
+)
+
+// Deadzone is the stick deflection needed to enter a sector.
+const Deadzone = 16000
+
+// Layout is a radial keyboard. Each group of letters is a sector,
+// clockwise from up.
+type Layout struct {
+	Groups []string
+}
+
+var DefaultLayout = &Layout{
+	Groups: []string{"abc", "def", "ghi", "jkl", "mno", "pqrs", "tuv", "wxyz"},
+}
+
+func (l *Layout) SectorOf(r rune) int {
+	r = unicode.ToLower(r)
+	for i, group := range l.Groups {
+		if strings.ContainsRune(group, r) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *Layout) Sector(stick gamepad.Vec) int {
+	return stick.Sector(len(l.Groups), Deadzone)
+}
+
+// Sequence returns the sectors a word passes through, with repeated
+// sectors collapsed. It returns nil if the word has letters that
+// aren't in the layout.
+func (l *Layout) Sequence(word string) []int {
+	var seq []int
+	for _, r := range word {
+		sector := l.SectorOf(r)
+		if sector < 0 {
+			return nil
+		}
+		if len(seq) == 0 || seq[len(seq)-1] != sector {
+			seq = append(seq, sector)
+		}
+	}
+	return seq
+}
+
+// Trace records the sectors the stick moved through.
+type Trace struct {
+	path []int
+}
+
+func (t *Trace) Add(sector int) {
+	if sector < 0 {
+		return
+	}
+	if n := len(t.path); n > 0 && t.path[n-1] == sector {
+		return
+	}
+	t.path = append(t.path, sector)
+}
+
+func (t *Trace) Path() []int {
+	return t.path
+}
+
+func (t *Trace) Len() int {
+	return len(t.path)
+}
+
+func (t *Trace) Reset() {
+	t.path = t.path[:0]
+}
+
+type entry struct {
+	word string
+	seq  []int
+}
+
+type Decoder struct {
+	Layout     *Layout
+	Dict       *dict.Dict
+	MaxResults int
+
+	// words indexed by their first and last sector
+	index map[[2]int][]entry
+}
+
+func NewDecoder(layout *Layout, d *dict.Dict) *Decoder {
+	dec := &Decoder{
+		Layout:     layout,
+		Dict:       d,
+		MaxResults: 8,
+		index:      make(map[[2]int][]entry),
+	}
+	for _, word := range d.Words() {
+		seq := layout.Sequence(word)
+		if seq == nil {
+			continue
+		}
+		key := [2]int{seq[0], seq[len(seq)-1]}
+		dec.index[key] = append(dec.index[key], entry{word, seq})
+	}
+	return dec
+}
+
+// Decode returns the words matching a traced path, most likely first.
+// A word matches if its sectors appear in order along the path, starting
+// and ending on the same sectors. Sectors the stick merely passed over on
+// the way are free; detours that don't lie on the shortest arc between
+// two letters make a word less likely.
+func (dec *Decoder) Decode(path []int) []string {
+	if len(path) == 0 {
+		return nil
+	}
+	type match struct {
+		word string
+		cost int
+		freq int
+	}
+	var matches []match
+	key := [2]int{path[0], path[len(path)-1]}
+	for _, e := range dec.index[key] {
+		cost, ok := dec.cost(path, e.seq)
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{e.word, cost, dec.Dict.Freq(e.word)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].cost != matches[j].cost {
+			return matches[i].cost < matches[j].cost
+		}
+		if matches[i].freq != matches[j].freq {
+			return matches[i].freq > matches[j].freq
+		}
+		return len(matches[i].word) < len(matches[j].word)
+	})
+
+	var words []string
+	for _, m := range matches {
+		if len(words) >= dec.MaxResults {
+			break
+		}
+		words = append(words, m.word)
+	}
+	return words
+}
+
+// cost matches seq as a subsequence of path, greedily, and counts the
+// path sectors that aren't explained by moving along the rim.
+func (dec *Decoder) cost(path, seq []int) (int, bool) {
+	n := len(dec.Layout.Groups)
+	cost := 0
+	p := 0
+	for i, sector := range seq {
+		start := p
+		for p < len(path) && path[p] != sector {
+			p++
+		}
+		if p >= len(path) {
+			return 0, false
+		}
+		if i > 0 {
+			skipped := p - start
+			if extra := skipped - (arc(seq[i-1], sector, n) - 1); extra > 0 {
+				cost += extra
+			}
+		}
+		p++
+	}
+	// trailing sectors after the last letter
+	cost += len(path) - p
+	return cost, true
+}
+
+// arc is the number of steps between two sectors along the rim.
+func arc(a, b, n int) int {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	if n-d < d {
+		return n - d
+	}
+	return d
+}
@@ -0,0 +1,244 @@
+package t9
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nvlled/gosn30/dict"
+	"github.com/nvlled/gosn30/gamepad"
+)
+
+// Groups are the letters on each key, like 2-9 on a phone keypad.
+// Keys are the dpad (left up right down) followed by the face buttons
+// (Y X A B), see Key.
+var Groups = []string{"abc", "def", "ghi", "jkl", "mno", "pqrs", "tuv", "wxyz"}
+
+// Key returns the key index of a dpad or face button event, or -1.
+func Key(event *gamepad.Event) int {
+	switch {
+	case event.IsDpad(gamepad.DirLeft):
+		return 0
+	case event.IsDpad(gamepad.DirUp):
+		return 1
+	case event.IsDpad(gamepad.DirRight):
+		return 2
+	case event.IsDpad(gamepad.DirDown):
+		return 3
+	case event.IsButton(gamepad.ButtonY):
+		return 4
+	case event.IsButton(gamepad.ButtonX):
+		return 5
+	case event.IsButton(gamepad.ButtonA):
+		return 6
+	case event.IsButton(gamepad.ButtonB):
+		return 7
+	}
+	return -1
+}
+
+func KeyOf(r rune) int {
+	for i, group := range Groups {
+		if strings.ContainsRune(group, r) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Edit describes how to rewrite the text being composed: erase Erase
+// characters before the cursor, then type Text.
+type Edit struct {
+	Erase int
+	Text  string
+}
+
+// MultiTap cycles through the letters of a key when it's pressed again
+// within Timeout. Otherwise the letter is kept and a new one starts.
+type MultiTap struct {
+	Timeout time.Duration
+
+	key   int
+	index int
+	last  time.Time
+}
+
+func NewMultiTap() *MultiTap {
+	return &MultiTap{Timeout: 800 * time.Millisecond, key: -1}
+}
+
+func (m *MultiTap) Press(key int, now time.Time) Edit {
+	if key < 0 || key >= len(Groups) {
+		return Edit{}
+	}
+	group := Groups[key]
+	if key == m.key && now.Sub(m.last) < m.Timeout {
+		m.index = (m.index + 1) % len(group)
+		m.last = now
+		return Edit{Erase: 1, Text: group[m.index : m.index+1]}
+	}
+	m.key = key
+	m.index = 0
+	m.last = now
+	return Edit{Text: group[:1]}
+}
+
+// Commit ends the current letter so the next press of the same key
+// starts a new one.
+func (m *MultiTap) Commit() {
+	m.key = -1
+}
+
+// Predictor resolves a sequence of key presses against a dictionary.
+type Predictor struct {
+	Dict *dict.Dict
+
+	// words indexed by their key sequence
+	index   map[string][]string
+	seq     []byte
+	matches []string
+	choice  int
+	shown   string
+}
+
+func NewPredictor(d *dict.Dict) *Predictor {
+	p := &Predictor{
+		Dict:  d,
+		index: make(map[string][]string),
+	}
+	for _, word := range d.Words() {
+		seq := Sequence(word)
+		if seq == "" {
+			continue
+		}
+		p.index[seq] = append(p.index[seq], word)
+	}
+	for _, words := range p.index {
+		d.ByFreq(words)
+	}
+	return p
+}
+
+// Sequence returns the key sequence that types a word, or "" if some
+// letter isn't on a key.
+func Sequence(word string) string {
+	seq := make([]byte, 0, len(word))
+	for _, r := range word {
+		key := KeyOf(r)
+		if key < 0 {
+			return ""
+		}
+		seq = append(seq, byte('0'+key))
+	}
+	return string(seq)
+}
+
+func (p *Predictor) Matches() []string {
+	return p.matches
+}
+
+func (p *Predictor) Word() string {
+	return p.shown
+}
+
+// Choice is the index of the shown word in the matches.
+func (p *Predictor) Choice() int {
+	return p.choice
+}
+
+func (p *Predictor) Press(key int) Edit {
+	if key < 0 || key >= len(Groups) {
+		return Edit{}
+	}
+	p.seq = append(p.seq, byte('0'+key))
+	p.lookup()
+	return p.show()
+}
+
+func (p *Predictor) Backspace() Edit {
+	if len(p.seq) == 0 {
+		return Edit{Erase: 1}
+	}
+	p.seq = p.seq[:len(p.seq)-1]
+	p.lookup()
+	return p.show()
+}
+
+// Next cycles to the next matching word.
+func (p *Predictor) Next() Edit {
+	if len(p.matches) < 2 {
+		return Edit{}
+	}
+	p.choice = (p.choice + 1) % len(p.matches)
+	return p.show()
+}
+
+// Commit accepts the shown word and starts a new one.
+func (p *Predictor) Commit() string {
+	word := p.shown
+	p.seq = p.seq[:0]
+	p.matches = nil
+	p.choice = 0
+	p.shown = ""
+	return word
+}
+
+func (p *Predictor) lookup() {
+	p.choice = 0
+	p.matches = nil
+	if len(p.seq) == 0 {
+		return
+	}
+	seq := string(p.seq)
+	p.matches = append(p.matches, p.index[seq]...)
+
+	// words still being typed: show prefixes of longer words, ordered
+	// by their most likely completion
+	exact := make(map[string]bool)
+	for _, m := range p.matches {
+		exact[m] = true
+	}
+	best := make(map[string]int)
+	var prefixes []string
+	for s, words := range p.index {
+		if len(s) <= len(seq) || !strings.HasPrefix(s, seq) {
+			continue
+		}
+		for _, w := range words {
+			prefix := string([]rune(w)[:len(seq)])
+			if exact[prefix] {
+				continue
+			}
+			freq, ok := best[prefix]
+			if !ok {
+				prefixes = append(prefixes, prefix)
+			}
+			if f := p.Dict.Freq(w); !ok || f > freq {
+				best[prefix] = f
+			}
+		}
+	}
+	sort.SliceStable(prefixes, func(i, j int) bool {
+		return best[prefixes[i]] > best[prefixes[j]]
+	})
+	p.matches = append(p.matches, prefixes...)
+
+	if len(p.matches) == 0 {
+		var letters []byte
+		for _, key := range p.seq {
+			letters = append(letters, Groups[key-'0'][0])
+		}
+		p.matches = []string{string(letters)}
+	}
+}
+
+func (p *Predictor) show() Edit {
+	next := ""
+	if len(p.matches) > 0 {
+		next = p.matches[p.choice]
+	}
+	edit := Edit{Erase: utf8.RuneCountInString(p.shown), Text: next}
+	p.shown = next
+	return edit
+}
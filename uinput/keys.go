@@ -0,0 +1,192 @@
+package uinput
+
+import "strconv"
+
+// evdev key codes, from linux/input-event-codes.h
+const (
+	keyEsc        = 1
+	keyMinus      = 12
+	keyEqual      = 13
+	keyBackspace  = 14
+	keyTab        = 15
+	keyLeftBrace  = 26
+	keyRightBrace = 27
+	keyEnter      = 28
+	keyLeftCtrl   = 29
+	keySemicolon  = 39
+	keyApostrophe = 40
+	keyGrave      = 41
+	keyLeftShift  = 42
+	keyBackslash  = 43
+	keyComma      = 51
+	keyDot        = 52
+	keySlash      = 53
+	keyRightShift = 54
+	keyKPAsterisk = 55
+	keyLeftAlt    = 56
+	keySpace      = 57
+	keyCapsLock   = 58
+	keyF1         = 59
+	keyNumLock    = 69
+	keyScrollLock = 70
+	keyKPMinus    = 74
+	keyKPPlus     = 78
+	keyKPDot      = 83
+	keyF11        = 87
+	keyF12        = 88
+	keyKPEnter    = 96
+	keyRightCtrl  = 97
+	keyKPSlash    = 98
+	keySysRq      = 99
+	keyRightAlt   = 100
+	keyHome       = 102
+	keyUp         = 103
+	keyPageUp     = 104
+	keyLeft       = 105
+	keyRight      = 106
+	keyEnd        = 107
+	keyDown       = 108
+	keyPageDown   = 109
+	keyInsert     = 110
+	keyDelete     = 111
+	keyPause      = 119
+	keyLeftMeta   = 125
+	keyRightMeta  = 126
+	keyCompose    = 127
+)
+
+// key is the evdev key that types a keysym, and whether shift is
+// needed for it.
+type key struct {
+	code  uint16
+	shift bool
+}
+
+// keys maps keysym names to keys, assuming a US layout on the
+// receiving side.
+var keys = map[string]key{
+	"Escape":    {keyEsc, false},
+	"BackSpace": {keyBackspace, false},
+	"Tab":       {keyTab, false},
+	"Return":    {keyEnter, false},
+	"space":     {keySpace, false},
+	"Delete":    {keyDelete, false},
+	"Insert":    {keyInsert, false},
+	"Home":      {keyHome, false},
+	"End":       {keyEnd, false},
+	"Prior":     {keyPageUp, false},
+	"Next":      {keyPageDown, false},
+	"Left":      {keyLeft, false},
+	"Up":        {keyUp, false},
+	"Right":     {keyRight, false},
+	"Down":      {keyDown, false},
+
+	"Print":       {keySysRq, false},
+	"Scroll_Lock": {keyScrollLock, false},
+	"Pause":       {keyPause, false},
+	"Num_Lock":    {keyNumLock, false},
+	"Caps_Lock":   {keyCapsLock, false},
+	"Menu":        {keyCompose, false},
+
+	"Shift_L":          {keyLeftShift, false},
+	"Shift_R":          {keyRightShift, false},
+	"Control_L":        {keyLeftCtrl, false},
+	"Control_R":        {keyRightCtrl, false},
+	"Alt_L":            {keyLeftAlt, false},
+	"Alt_R":            {keyRightAlt, false},
+	"ISO_Level3_Shift": {keyRightAlt, false},
+	"ISO_Left_Tab":     {keyTab, true},
+	"Super_L":          {keyLeftMeta, false},
+	"Super_R":          {keyRightMeta, false},
+
+	"KP_Divide":   {keyKPSlash, false},
+	"KP_Multiply": {keyKPAsterisk, false},
+	"KP_Subtract": {keyKPMinus, false},
+	"KP_Add":      {keyKPPlus, false},
+	"KP_Enter":    {keyKPEnter, false},
+	"KP_Decimal":  {keyKPDot, false},
+
+	"minus":        {keyMinus, false},
+	"equal":        {keyEqual, false},
+	"bracketleft":  {keyLeftBrace, false},
+	"bracketright": {keyRightBrace, false},
+	"semicolon":    {keySemicolon, false},
+	"apostrophe":   {keyApostrophe, false},
+	"grave":        {keyGrave, false},
+	"backslash":    {keyBackslash, false},
+	"comma":        {keyComma, false},
+	"period":       {keyDot, false},
+	"slash":        {keySlash, false},
+
+	"exclam":      {2, true},
+	"at":          {3, true},
+	"numbersign":  {4, true},
+	"dollar":      {5, true},
+	"percent":     {6, true},
+	"asciicircum": {7, true},
+	"ampersand":   {8, true},
+	"asterisk":    {9, true},
+	"parenleft":   {10, true},
+	"parenright":  {11, true},
+	"underscore":  {keyMinus, true},
+	"plus":        {keyEqual, true},
+	"braceleft":   {keyLeftBrace, true},
+	"braceright":  {keyRightBrace, true},
+	"colon":       {keySemicolon, true},
+	"quotedbl":    {keyApostrophe, true},
+	"asciitilde":  {keyGrave, true},
+	"bar":         {keyBackslash, true},
+	"less":        {keyComma, true},
+	"greater":     {keyDot, true},
+	"question":    {keySlash, true},
+}
+
+// chars maps the printable ASCII characters to their keysym names.
+var chars = map[rune]string{
+	' ': "space", '\n': "Return", '\t': "Tab",
+	'-': "minus", '=': "equal", '[': "bracketleft", ']': "bracketright",
+	';': "semicolon", '\'': "apostrophe", '`': "grave", '\\': "backslash",
+	',': "comma", '.': "period", '/': "slash",
+	'!': "exclam", '@': "at", '#': "numbersign", '$': "dollar",
+	'%': "percent", '^': "asciicircum", '&': "ampersand", '*': "asterisk",
+	'(': "parenleft", ')': "parenright", '_': "underscore", '+': "plus",
+	'{': "braceleft", '}': "braceright", ':': "colon", '"': "quotedbl",
+	'~': "asciitilde", '|': "bar", '<': "less", '>': "greater", '?': "question",
+}
+
+func init() {
+	rows := []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+	first := []uint16{2, 16, 30, 44}
+	for i, row := range rows {
+		for j, c := range row {
+			code := first[i] + uint16(j)
+			keys[string(c)] = key{code, false}
+			if c >= 'a' && c <= 'z' {
+				keys[string(c-'a'+'A')] = key{code, true}
+			}
+		}
+	}
+	for i := 0; i < 10; i++ {
+		keys["F"+strconv.Itoa(i+1)] = key{keyF1 + uint16(i), false}
+	}
+	keys["F11"] = key{keyF11, false}
+	keys["F12"] = key{keyF12, false}
+	keypad := []uint16{82, 79, 80, 81, 75, 76, 77, 71, 72, 73}
+	for i, code := range keypad {
+		keys["KP_"+strconv.Itoa(i)] = key{code, false}
+	}
+}
+
+// lookup returns the key that types a keysym name or a single
+// character.
+func lookup(keysym string) (key, bool) {
+	if k, ok := keys[keysym]; ok {
+		return k, true
+	}
+	if r := []rune(keysym); len(r) == 1 {
+		if name, ok := chars[r[0]]; ok {
+			return keys[name], true
+		}
+	}
+	return key{}, false
+}
@@ -0,0 +1,350 @@
+package uinput
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/nvlled/gosn30/modifier"
+)
+
+// Path is the uinput device node.
+const Path = "/dev/uinput"
+
+// Mouse buttons, numbered like X's so callers don't care which backend
+// they have.
+const (
+	MbLeft = iota + 1
+	MbMid
+	MbRight
+	MbWheelUp
+	MbWheelDown
+	MbWheelLeft
+	MbWheelRight
+)
+
+const (
+	evSyn = 0x00
+	evKey = 0x01
+	evRel = 0x02
+
+	synReport = 0
+
+	relX           = 0x00
+	relY           = 0x01
+	relHWheel      = 0x06
+	relWheel       = 0x08
+	relWheelHiRes  = 0x0b
+	relHWheelHiRes = 0x0c
+
+	btnLeft   = 0x110
+	btnRight  = 0x111
+	btnMiddle = 0x112
+
+	// a wheel notch in high-resolution units
+	wheelStep = 120
+
+	uiDevCreate  = 0x5501
+	uiDevDestroy = 0x5502
+	uiDevSetup   = 0x405c5503
+	uiSetEvBit   = 0x40045564
+	uiSetKeyBit  = 0x40045565
+	uiSetRelBit  = 0x40045566
+
+	busVirtual = 0x06
+
+	// how long udev and the display server take to pick up a new
+	// device; events sent before that are lost
+	settleTime = 200 * time.Millisecond
+)
+
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+type setup struct {
+	Bustype      uint16
+	Vendor       uint16
+	Product      uint16
+	Version      uint16
+	Name         [80]byte
+	FFEffectsMax uint32
+}
+
+// Device is a virtual keyboard and mouse made through uinput. It works
+// without X, on Wayland and on the console, but keys are mapped for a
+// US layout, and text that layout can't type is entered with the
+// ctrl+shift+u unicode sequence, which only GTK and IBus understand.
+type Device struct {
+	file   *os.File
+	wheelX int
+	wheelY int
+	modifier.State
+	// failing is set while writes fail, so the error is only reported
+	// once
+	failing bool
+
+	KeyDelay int
+}
+
+// Open creates the virtual device.
+func Open() (*Device, error) {
+	file, err := os.OpenFile(Path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{file: file, KeyDelay: 12000}
+	if err = d.create(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Device) ioctl(req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.file.Fd(), req, arg); errno != 0 {
+		return fmt.Errorf("uinput ioctl %#x: %v", req, errno)
+	}
+	return nil
+}
+
+func (d *Device) create() error {
+	for _, ev := range []uintptr{evSyn, evKey, evRel} {
+		if err := d.ioctl(uiSetEvBit, ev); err != nil {
+			return err
+		}
+	}
+	for code := uintptr(1); code < 0x100; code++ {
+		if err := d.ioctl(uiSetKeyBit, code); err != nil {
+			return err
+		}
+	}
+	for _, btn := range []uintptr{btnLeft, btnRight, btnMiddle} {
+		if err := d.ioctl(uiSetKeyBit, btn); err != nil {
+			return err
+		}
+	}
+	for _, rel := range []uintptr{relX, relY, relWheel, relHWheel, relWheelHiRes, relHWheelHiRes} {
+		if err := d.ioctl(uiSetRelBit, rel); err != nil {
+			return err
+		}
+	}
+	s := setup{Bustype: busVirtual, Version: 1}
+	copy(s.Name[:], "gosn30 virtual input")
+	if err := d.ioctl(uiDevSetup, uintptr(unsafe.Pointer(&s))); err != nil {
+		return err
+	}
+	if err := d.ioctl(uiDevCreate, 0); err != nil {
+		return err
+	}
+	time.Sleep(settleTime)
+	return nil
+}
+
+func (d *Device) Close() error {
+	d.ioctl(uiDevDestroy, 0)
+	return d.file.Close()
+}
+
+func (d *Device) emit(typ, code uint16, value int32) error {
+	ev := inputEvent{Type: typ, Code: code, Value: value}
+	buf := (*[unsafe.Sizeof(ev)]byte)(unsafe.Pointer(&ev))[:]
+	n, err := d.file.Write(buf)
+	if err == nil && n < len(buf) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+func (d *Device) sync() error {
+	return d.emit(evSyn, synReport, 0)
+}
+
+// report prints a failed write, once until writes work again.
+func (d *Device) report(err error) {
+	if err == nil {
+		d.failing = false
+	} else if !d.failing {
+		d.failing = true
+		fmt.Printf("uinput: %v\n", err)
+	}
+}
+
+func (d *Device) key(code uint16, down bool) error {
+	value := int32(0)
+	if down {
+		value = 1
+	}
+	if err := d.emit(evKey, code, value); err != nil {
+		return err
+	}
+	return d.sync()
+}
+
+func (d *Device) MouseMove(x, y int) {
+	d.report(d.move(x, y))
+}
+
+func (d *Device) move(x, y int) error {
+	if x != 0 {
+		if err := d.emit(evRel, relX, int32(x)); err != nil {
+			return err
+		}
+	}
+	if y != 0 {
+		if err := d.emit(evRel, relY, int32(y)); err != nil {
+			return err
+		}
+	}
+	return d.sync()
+}
+
+// Scroll scrolls by 1/120 of a notch, right and down being positive.
+// The classic wheel events are sent too, once a whole notch has built
+// up, for clients that don't read the high-resolution ones.
+func (d *Device) Scroll(dx, dy int) {
+	d.report(d.scroll(dx, dy))
+}
+
+func (d *Device) scroll(dx, dy int) error {
+	if dx != 0 {
+		if err := d.emit(evRel, relHWheelHiRes, int32(dx)); err != nil {
+			return err
+		}
+		d.wheelX += dx
+		if notches := d.wheelX / wheelStep; notches != 0 {
+			d.wheelX -= notches * wheelStep
+			if err := d.emit(evRel, relHWheel, int32(notches)); err != nil {
+				return err
+			}
+		}
+	}
+	if dy != 0 {
+		// the wheel axis points up
+		if err := d.emit(evRel, relWheelHiRes, int32(-dy)); err != nil {
+			return err
+		}
+		d.wheelY += dy
+		if notches := d.wheelY / wheelStep; notches != 0 {
+			d.wheelY -= notches * wheelStep
+			if err := d.emit(evRel, relWheel, int32(-notches)); err != nil {
+				return err
+			}
+		}
+	}
+	return d.sync()
+}
+
+func buttonCode(mouseButton int) (uint16, bool) {
+	switch mouseButton {
+	case MbLeft:
+		return btnLeft, true
+	case MbMid:
+		return btnMiddle, true
+	case MbRight:
+		return btnRight, true
+	}
+	return 0, false
+}
+
+// MouseDown presses a button. The wheel "buttons" scroll on press.
+func (d *Device) MouseDown(mouseButton int) {
+	switch mouseButton {
+	case MbWheelUp:
+		d.Scroll(0, -wheelStep)
+	case MbWheelDown:
+		d.Scroll(0, wheelStep)
+	case MbWheelLeft:
+		d.Scroll(-wheelStep, 0)
+	case MbWheelRight:
+		d.Scroll(wheelStep, 0)
+	default:
+		if code, ok := buttonCode(mouseButton); ok {
+			d.report(d.key(code, true))
+		}
+	}
+}
+
+func (d *Device) MouseUp(mouseButton int) {
+	if code, ok := buttonCode(mouseButton); ok {
+		d.report(d.key(code, false))
+	}
+}
+
+func (d *Device) MouseClick(mouseButton int) {
+	d.MouseDown(mouseButton)
+	d.MouseUp(mouseButton)
+}
+
+func (d *Device) pause() {
+	time.Sleep(time.Duration(d.KeyDelay) * time.Microsecond)
+}
+
+// press types a combination of keys, such as shift and a letter.
+// Keys that went down are released even if a write failed on the way.
+func (d *Device) press(codes []uint16) {
+	var err error
+	down := 0
+	for down < len(codes) {
+		if err = d.key(codes[down], true); err != nil {
+			break
+		}
+		down++
+	}
+	for i := down - 1; i >= 0; i-- {
+		if uerr := d.key(codes[i], false); err == nil {
+			err = uerr
+		}
+	}
+	d.report(err)
+	d.pause()
+}
+
+// KeyPress types a keysym, or a combination like "Control_L+c".
+func (d *Device) KeyPress(keyseq string) {
+	var codes []uint16
+	for _, name := range strings.Split(d.Apply(keyseq), "+") {
+		k, ok := lookup(name)
+		if !ok {
+			fmt.Printf("uinput: no key for %v\n", name)
+			return
+		}
+		if k.shift {
+			codes = append(codes, keyLeftShift)
+		}
+		codes = append(codes, k.code)
+	}
+	d.press(codes)
+}
+
+func (d *Device) EnterText(text string) {
+	for _, r := range text {
+		if k, ok := lookup(string(r)); ok {
+			if k.shift {
+				d.press([]uint16{keyLeftShift, k.code})
+			} else {
+				d.press([]uint16{k.code})
+			}
+			continue
+		}
+		// ctrl+shift+u, the code point in hex, then space
+		u, _ := lookup("u")
+		d.press([]uint16{keyLeftCtrl, keyLeftShift, u.code})
+		for _, c := range fmt.Sprintf("%x", r) {
+			k, _ := lookup(string(c))
+			d.press([]uint16{k.code})
+		}
+		d.press([]uint16{keySpace})
+	}
+}
+
+// SetKeyboardGroup does nothing: the keyboard layout belongs to
+// whatever reads the device.
+func (d *Device) SetKeyboardGroup(group int) {}
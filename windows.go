@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nvlled/gosn30/gamepad"
+	"github.com/nvlled/gosn30/hud"
+	"github.com/nvlled/gosn30/inject"
+	"github.com/nvlled/gosn30/layout"
+)
+
+func (c *Controller) findWindow(name string) (inject.Search, bool) {
+	w, ok := c.profile.Windows[name]
+	if !ok {
+		fmt.Printf("no window named %q in the profile\n", name)
+	}
+	return inject.Search(w), ok
+}
+
+func (c *Controller) showSwitcher() {
+	row := []string{}
+	for _, name := range c.switchNames {
+		if r := []rune(name); len(r) > 6 {
+			name = string(r[:6])
+		}
+		row = append(row, name)
+	}
+	title := fmt.Sprintf("%v/%v %v", c.switchIndex+1, len(c.switchNames), c.switchNames[c.switchIndex])
+	c.Display.Show(title, hud.Grid{row}, 0, c.switchIndex)
+}
+
+func (c *Controller) closeSwitcher() {
+	c.switching = false
+	c.Display.Hide()
+}
+
+func (c *Controller) manageWindows(fn func(wm inject.WindowManager)) {
+	if !c.queue.CanManage() {
+		fmt.Println("window actions need an X output")
+		return
+	}
+	c.queue.Run(func(out inject.Injector) { fn(out.(inject.WindowManager)) })
+}
+
+func (c *Controller) withActiveWindow(fn func(wm inject.WindowManager, w inject.Window)) {
+	c.manageWindows(func(wm inject.WindowManager) {
+		if w, ok := wm.ActiveWindow(); ok {
+			fn(wm, w)
+		}
+	})
+}
+
+// windowList returns the windows on the desktop and which is active
+func (c *Controller) windowList() ([]inject.Window, int) {
+	var windows []inject.Window
+	active := -1
+	c.manageWindows(func(wm inject.WindowManager) {
+		windows = wm.Windows()
+		if w, ok := wm.ActiveWindow(); ok {
+			for i := range windows {
+				if windows[i] == w {
+					active = i
+				}
+			}
+		}
+	})
+	return windows, active
+}
+
+func (c *Controller) focusWindow(step int) {
+	windows, active := c.windowList()
+	if len(windows) == 0 {
+		return
+	}
+	if active < 0 && step < 0 {
+		active = 0
+	}
+	w := windows[((active+step)%len(windows)+len(windows))%len(windows)]
+	c.manageWindows(func(wm inject.WindowManager) { wm.ActivateWindow(w) })
+}
+
+func (c *Controller) openSwitcher() {
+	windows, active := c.windowList()
+	if len(windows) == 0 {
+		return
+	}
+	c.switchWindows = windows
+	names := make([]string, len(windows))
+	c.manageWindows(func(wm inject.WindowManager) {
+		for i, w := range windows {
+			names[i] = wm.WindowName(w)
+		}
+	})
+	c.switchNames = names
+	c.switchIndex = (active + 1) % len(windows)
+	c.switching = true
+	c.showSwitcher()
+}
+
+func (c *Controller) processSwitcherInput(event *gamepad.Event) {
+	if !event.Pressed {
+		return
+	}
+	n := len(c.switchWindows)
+	if event.IsDpad(gamepad.DirLeft) || event.IsButton(gamepad.ButtonL) {
+		c.switchIndex = (c.switchIndex - 1 + n) % n
+	} else if event.IsDpad(gamepad.DirRight) || event.IsButton(gamepad.ButtonR) {
+		c.switchIndex = (c.switchIndex + 1) % n
+	} else if event.IsButton(gamepad.ButtonA) {
+		w := c.switchWindows[c.switchIndex]
+		c.closeSwitcher()
+		c.manageWindows(func(wm inject.WindowManager) { wm.ActivateWindow(w) })
+		return
+	} else if event.IsButton(gamepad.ButtonB) || event.IsButton(gamepad.ButtonSelect) {
+		c.closeSwitcher()
+		return
+	}
+	c.showSwitcher()
+}
+
+// runAction runs the actions that don't depend on the entry method, so
+// they can be bound in any mode
+func (c *Controller) runAction(action string) {
+	switch {
+	case action == layout.ActionSwitcher:
+		c.openSwitcher()
+	case action == layout.ActionFocusNext:
+		c.focusWindow(1)
+	case action == layout.ActionFocusPrev:
+		c.focusWindow(-1)
+	case strings.HasPrefix(action, layout.ActionMove), strings.HasPrefix(action, layout.ActionResize):
+		arg := action[strings.Index(action, ":")+1:]
+		x, y, ok := parsePair(arg)
+		if !ok {
+			fmt.Printf("bad action: %v\n", action)
+			return
+		}
+		c.withActiveWindow(func(wm inject.WindowManager, w inject.Window) {
+			if strings.HasPrefix(action, layout.ActionMove) {
+				wm.MoveWindowBy(w, x, y)
+			} else {
+				wm.ResizeWindowBy(w, x, y)
+			}
+		})
+	case action == layout.ActionMaximize:
+		c.withActiveWindow(inject.WindowManager.ToggleMaximized)
+	case action == layout.ActionMinimize:
+		c.withActiveWindow(inject.WindowManager.MinimizeWindow)
+	case action == layout.ActionClose:
+		c.withActiveWindow(inject.WindowManager.CloseWindow)
+	case strings.HasPrefix(action, layout.ActionDesktop), strings.HasPrefix(action, layout.ActionToDesktop):
+		arg := action[strings.Index(action, ":")+1:]
+		c.manageWindows(func(wm inject.WindowManager) {
+			current, count := wm.Desktop()
+			desktop, ok := desktopArg(arg, current, count)
+			if !ok {
+				fmt.Printf("bad action: %v\n", action)
+				return
+			}
+			if strings.HasPrefix(action, layout.ActionDesktop) {
+				wm.SetDesktop(desktop)
+			} else if w, ok := wm.ActiveWindow(); ok {
+				wm.SetWindowDesktop(w, desktop)
+			}
+		})
+	case strings.HasPrefix(action, layout.ActionSend):
+		arg := strings.SplitN(strings.TrimPrefix(action, layout.ActionSend), ":", 2)
+		if len(arg) < 2 {
+			fmt.Printf("bad action: %v\n", action)
+			return
+		}
+		if !c.queue.CanTarget() {
+			fmt.Println("sending to a window needs an X output")
+		} else if s, ok := c.findWindow(arg[0]); ok {
+			c.queue.SendTo(s, func(out inject.Injector) { out.KeyPress(arg[1]) })
+		}
+	case strings.HasPrefix(action, layout.ActionLock):
+		name := strings.TrimPrefix(action, layout.ActionLock)
+		if !c.queue.CanTarget() {
+			fmt.Println("sending to a window needs an X output")
+		} else if s, ok := c.findWindow(name); ok {
+			c.queue.LockTo(s)
+			c.notify("output locked to " + name)
+		}
+	case action == layout.ActionUnlock:
+		c.queue.Unlock()
+		c.notify("output unlocked")
+	default:
+		fmt.Printf("unknown action: %v\n", action)
+	}
+}
+
+// runBinding runs the profile binding whose combo the event completes,
+// and reports whether there was one.
+func (c *Controller) runBinding(event *gamepad.Event) bool {
+	if !event.Pressed || event.InputType != gamepad.InputButton {
+		return false
+	}
+next:
+	for _, b := range c.bindings {
+		if event.InputValue != b.button {
+			continue
+		}
+		for _, h := range b.held {
+			if !c.gpad.IsButtonDown(uint8(h)) {
+				continue next
+			}
+		}
+		if layout.IsAction(b.action) {
+			c.runAction(b.action)
+		} else {
+			c.out.KeyPress(b.action)
+		}
+		return true
+	}
+	return false
+}
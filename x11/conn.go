@@ -0,0 +1,434 @@
+package x11
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Conn is a minimal X11 client connection: enough of the core protocol
+// and extensions to inject input. Requests are sent in order and
+// requests with replies wait for them.
+type Conn struct {
+	conn net.Conn
+	mu   sync.Mutex
+	seq  uint16
+
+	replies chan reply
+	pending uint16
+	waiting bool
+	pmu     sync.Mutex
+	// dead is closed once the connection fails, err saying why
+	dead    chan struct{}
+	err     error
+	dieOnce sync.Once
+	// asyncErr is the first X error for a request without a reply,
+	// handed out by the next Sync
+	asyncErr error
+
+	atomCache map[string]uint32
+	amu       sync.Mutex
+
+	// resource IDs are idBase with bits of idMask set
+	idBase uint32
+	idMask uint32
+	nextID uint32
+	idmu   sync.Mutex
+
+	Root       uint32
+	Width      int
+	Height     int
+	MinKeycode byte
+	MaxKeycode byte
+	// the pixel values of black and white on the screen
+	Black uint32
+	White uint32
+}
+
+type reply struct {
+	data []byte
+	err  error
+}
+
+var order = binary.LittleEndian
+
+// Dial connects to the display in DISPLAY.
+func Dial() (*Conn, error) {
+	return DialDisplay(os.Getenv("DISPLAY"))
+}
+
+// DialDisplay connects to a display like ":0" or "host:1.0".
+func DialDisplay(display string) (*Conn, error) {
+	i := strings.LastIndex(display, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("bad display %q", display)
+	}
+	host := display[:i]
+	number := display[i+1:]
+	if j := strings.Index(number, "."); j >= 0 {
+		number = number[:j]
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return nil, fmt.Errorf("bad display %q", display)
+	}
+	var conn net.Conn
+	var err error
+	if host == "" || host == "unix" {
+		conn, err = net.Dial("unix", "/tmp/.X11-unix/X"+number)
+	} else {
+		n, _ := strconv.Atoi(number)
+		conn, err = net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(6000+n)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{conn: conn, replies: make(chan reply, 1), dead: make(chan struct{})}
+	name, data := authCookie(host, number)
+	if err = c.setup(name, data); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.read()
+	return c, nil
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func pad(n int) int {
+	return (4 - n%4) % 4
+}
+
+// authCookie finds the MIT-MAGIC-COOKIE-1 for the display in the
+// Xauthority file.
+func authCookie(host, number string) (string, []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		path = os.Getenv("HOME") + "/.Xauthority"
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil
+	}
+	defer file.Close()
+	if host == "" || host == "unix" {
+		host, _ = os.Hostname()
+	}
+	r := bufio.NewReader(file)
+	readString := func() (string, error) {
+		var n uint16
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return "", err
+		}
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return string(b), err
+	}
+	for {
+		var family uint16
+		if err := binary.Read(r, binary.BigEndian, &family); err != nil {
+			return "", nil
+		}
+		addr, err1 := readString()
+		disp, err2 := readString()
+		name, err3 := readString()
+		data, err4 := readString()
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			return "", nil
+		}
+		// 256 is a local connection, 65535 any address
+		if (family == 256 && addr != host) || (disp != "" && disp != number) {
+			continue
+		}
+		if name == "MIT-MAGIC-COOKIE-1" {
+			return name, []byte(data)
+		}
+	}
+}
+
+func (c *Conn) setup(authName string, authData []byte) error {
+	req := make([]byte, 12)
+	req[0] = 'l'
+	order.PutUint16(req[2:], 11)
+	order.PutUint16(req[6:], uint16(len(authName)))
+	order.PutUint16(req[8:], uint16(len(authData)))
+	req = append(req, authName...)
+	req = append(req, make([]byte, pad(len(authName)))...)
+	req = append(req, authData...)
+	req = append(req, make([]byte, pad(len(authData)))...)
+	if _, err := c.conn.Write(req); err != nil {
+		return err
+	}
+
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, head); err != nil {
+		return err
+	}
+	data := make([]byte, int(order.Uint16(head[6:]))*4)
+	if _, err := io.ReadFull(c.conn, data); err != nil {
+		return err
+	}
+	if head[0] != 1 {
+		reason := data
+		if n := int(head[1]); n <= len(reason) {
+			reason = reason[:n]
+		}
+		return fmt.Errorf("X server refused the connection: %s", reason)
+	}
+	c.idBase = order.Uint32(data[4:])
+	c.idMask = order.Uint32(data[8:])
+	vendorLen := int(order.Uint16(data[16:]))
+	formats := int(data[21])
+	c.MinKeycode = data[26]
+	c.MaxKeycode = data[27]
+	screen := 32 + vendorLen + pad(vendorLen) + formats*8
+	if len(data) < screen+24 {
+		return errors.New("short X setup reply")
+	}
+	c.Root = order.Uint32(data[screen:])
+	c.White = order.Uint32(data[screen+8:])
+	c.Black = order.Uint32(data[screen+12:])
+	c.Width = int(order.Uint16(data[screen+20:]))
+	c.Height = int(order.Uint16(data[screen+22:]))
+	return nil
+}
+
+// read takes replies, errors and events off the connection. Replies go
+// to the request waiting for them, errors for other requests are kept
+// for Sync, and events are ignored since none are selected.
+func (c *Conn) read() {
+	buf := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(c.conn, buf); err != nil {
+			c.die(err)
+			return
+		}
+		var r reply
+		switch buf[0] {
+		case 0:
+			r.err = fmt.Errorf("X error %d for request %d.%d", buf[1], buf[10], order.Uint16(buf[8:]))
+		case 1:
+			r.data = make([]byte, 32+int(order.Uint32(buf[4:]))*4)
+			copy(r.data, buf)
+			if _, err := io.ReadFull(c.conn, r.data[32:]); err != nil {
+				c.die(err)
+				return
+			}
+		default:
+			continue
+		}
+		seq := order.Uint16(buf[2:])
+		c.pmu.Lock()
+		if c.waiting && seq == c.pending {
+			c.waiting = false
+			c.replies <- r
+		} else if r.err != nil && c.asyncErr == nil {
+			c.asyncErr = r.err
+		}
+		c.pmu.Unlock()
+	}
+}
+
+// die marks the connection dead, failing the request waiting for a
+// reply and every later one.
+func (c *Conn) die(err error) {
+	c.dieOnce.Do(func() {
+		c.pmu.Lock()
+		c.err = fmt.Errorf("X connection lost: %v", err)
+		c.waiting = false
+		c.pmu.Unlock()
+		close(c.dead)
+	})
+}
+
+// deadErr returns why the connection died, or nil while it's up.
+func (c *Conn) deadErr() error {
+	select {
+	case <-c.dead:
+		c.pmu.Lock()
+		defer c.pmu.Unlock()
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// send writes a request, whose length field is filled in.
+func (c *Conn) send(req []byte) error {
+	if err := c.deadErr(); err != nil {
+		return err
+	}
+	req = append(req, make([]byte, pad(len(req)))...)
+	order.PutUint16(req[2:], uint16(len(req)/4))
+	c.seq++
+	if _, err := c.conn.Write(req); err != nil {
+		c.die(err)
+		return c.deadErr()
+	}
+	return nil
+}
+
+// Send writes a request that has no reply. An X error it causes comes
+// out of the next Sync.
+func (c *Conn) Send(req []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.send(req)
+}
+
+// Call writes a request and waits for its reply.
+func (c *Conn) Call(req []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pmu.Lock()
+	c.waiting = true
+	c.pending = c.seq + 1
+	c.pmu.Unlock()
+	if err := c.send(req); err != nil {
+		c.pmu.Lock()
+		c.waiting = false
+		c.pmu.Unlock()
+		return nil, err
+	}
+	select {
+	case r := <-c.replies:
+		return r.data, r.err
+	case <-c.dead:
+		// the reply may have come in just before
+		select {
+		case r := <-c.replies:
+			return r.data, r.err
+		default:
+		}
+		return nil, c.deadErr()
+	}
+}
+
+// QueryExtension returns the major opcode of an extension.
+func (c *Conn) QueryExtension(name string) (byte, error) {
+	req := make([]byte, 8, 8+len(name))
+	req[0] = 98
+	order.PutUint16(req[4:], uint16(len(name)))
+	req = append(req, name...)
+	data, err := c.Call(req)
+	if err != nil {
+		return 0, err
+	}
+	if data[8] == 0 {
+		return 0, fmt.Errorf("X server has no %v extension", name)
+	}
+	return data[9], nil
+}
+
+// Sync waits until the server has handled every request sent so far,
+// and returns the first X error any of those without a reply caused.
+func (c *Conn) Sync() error {
+	// GetInputFocus, the usual round trip
+	if _, err := c.Call([]byte{43, 0, 0, 0}); err != nil {
+		return err
+	}
+	c.pmu.Lock()
+	defer c.pmu.Unlock()
+	err := c.asyncErr
+	c.asyncErr = nil
+	return err
+}
+
+// KeyboardMapping returns the keysyms of every keycode, starting at
+// MinKeycode.
+func (c *Conn) KeyboardMapping() ([][]uint32, error) {
+	count := int(c.MaxKeycode) - int(c.MinKeycode) + 1
+	data, err := c.Call([]byte{101, 0, 0, 0, c.MinKeycode, byte(count), 0, 0})
+	if err != nil {
+		return nil, err
+	}
+	per := int(data[1])
+	mapping := make([][]uint32, count)
+	for i := range mapping {
+		mapping[i] = make([]uint32, per)
+		for j := range mapping[i] {
+			mapping[i][j] = order.Uint32(data[32+(i*per+j)*4:])
+		}
+	}
+	return mapping, nil
+}
+
+// ChangeKeyboardMapping sets the keysyms of one keycode.
+func (c *Conn) ChangeKeyboardMapping(keycode byte, keysyms []uint32) error {
+	req := make([]byte, 8+4*len(keysyms))
+	copy(req, []byte{100, 1, 0, 0, keycode, byte(len(keysyms))})
+	for i, ks := range keysyms {
+		order.PutUint32(req[8+4*i:], ks)
+	}
+	return c.Send(req)
+}
+
+// QueryPointer returns where the pointer is on the root window.
+func (c *Conn) QueryPointer() (int, int, error) {
+	req := make([]byte, 8)
+	req[0] = 38
+	order.PutUint32(req[4:], c.Root)
+	data, err := c.Call(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(int16(order.Uint16(data[16:]))), int(int16(order.Uint16(data[18:]))), nil
+}
+
+// AtomName returns the name of an atom.
+func (c *Conn) AtomName(atom uint32) (string, error) {
+	req := make([]byte, 8)
+	req[0] = 17
+	order.PutUint32(req[4:], atom)
+	data, err := c.Call(req)
+	if err != nil {
+		return "", err
+	}
+	n := int(order.Uint16(data[8:]))
+	if 32+n > len(data) {
+		return "", errors.New("short GetAtomName reply")
+	}
+	return string(data[32 : 32+n]), nil
+}
+
+// InternAtom returns the atom for a name, creating it if needed.
+func (c *Conn) InternAtom(name string) (uint32, error) {
+	req := make([]byte, 8, 8+len(name))
+	req[0] = 16
+	order.PutUint16(req[4:], uint16(len(name)))
+	req = append(req, name...)
+	data, err := c.Call(req)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint32(data[8:]), nil
+}
+
+// Property returns the value of a window property, and its format: 8,
+// 16 or 32 bits per item. A missing property has no value.
+func (c *Conn) Property(window, property uint32) ([]byte, int, error) {
+	req := make([]byte, 24)
+	req[0] = 20
+	order.PutUint32(req[4:], window)
+	order.PutUint32(req[8:], property)
+	// any type, up to 64k
+	order.PutUint32(req[20:], 16384)
+	data, err := c.Call(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	format := int(data[1])
+	n := int(order.Uint32(data[16:])) * format / 8
+	if 32+n > len(data) {
+		return nil, 0, errors.New("short GetProperty reply")
+	}
+	return data[32 : 32+n], format, nil
+}
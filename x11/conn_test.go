@@ -0,0 +1,128 @@
+package x11
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestDeadConnection checks that requests fail rather than hang once
+// the server goes away.
+func TestDeadConnection(t *testing.T) {
+	client, server := net.Pipe()
+	c := &Conn{conn: client, replies: make(chan reply, 1), dead: make(chan struct{})}
+	go c.read()
+	go func() {
+		// take the request, then hang up without a reply
+		io.ReadFull(server, make([]byte, 4))
+		server.Close()
+	}()
+
+	for i := 0; i < 2; i++ {
+		done := make(chan error, 1)
+		go func() {
+			_, err := c.Call([]byte{43, 0, 0, 0})
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Fatalf("call %v on a dead connection succeeded", i+1)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("call %v on a dead connection hangs", i+1)
+		}
+	}
+}
+
+// TestSyncReportsError checks that an error for a request without a
+// reply comes out of the next Sync.
+func TestSyncReportsError(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := &Conn{conn: client, replies: make(chan reply, 1), dead: make(chan struct{})}
+	go c.read()
+	go func() {
+		// the failing request, then GetInputFocus
+		io.ReadFull(server, make([]byte, 8))
+		errEvent := make([]byte, 32)
+		errEvent[1] = 2 // BadValue
+		order.PutUint16(errEvent[2:], 1)
+		errEvent[10] = 132
+		server.Write(errEvent)
+		focus := make([]byte, 32)
+		focus[0] = 1
+		order.PutUint16(focus[2:], 2)
+		server.Write(focus)
+	}()
+
+	if err := c.Send([]byte{132, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Sync(); err == nil {
+		t.Error("Sync after a failed request returned nil")
+	}
+	if c.asyncErr != nil {
+		t.Errorf("error %v still kept after Sync", c.asyncErr)
+	}
+}
+
+// TestClientMessage checks the SendEvent request that asks the window
+// manager for something.
+func TestClientMessage(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := &Conn{conn: client, Root: 0x100, dead: make(chan struct{}),
+		atomCache: map[string]uint32{"_NET_CLOSE_WINDOW": 7}}
+	got := make(chan []byte, 1)
+	go func() {
+		req := make([]byte, 44)
+		io.ReadFull(server, req)
+		got <- req
+	}()
+
+	if err := c.clientMessage(0x2a, "_NET_CLOSE_WINDOW", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	req := <-got
+	if req[0] != 25 || order.Uint16(req[2:]) != 11 {
+		t.Fatalf("request %v, length %v; want SendEvent, 11", req[0], order.Uint16(req[2:]))
+	}
+	if dest, mask := order.Uint32(req[4:]), order.Uint32(req[8:]); dest != 0x100 || mask != substructureRedirectMask|substructureNotifyMask {
+		t.Errorf("sent to %#x with mask %#x, want the root with the substructure masks", dest, mask)
+	}
+	ev := req[12:]
+	if ev[0] != clientMessage || ev[1] != 32 || order.Uint32(ev[4:]) != 0x2a || order.Uint32(ev[8:]) != 7 {
+		t.Errorf("event %v", ev)
+	}
+	if order.Uint32(ev[12:]) != 0 || order.Uint32(ev[16:]) != 1 {
+		t.Errorf("data %v, want 0, 1", ev[12:20])
+	}
+}
+
+// TestDrawText checks that text goes out as 16 bit characters, with
+// those the font can't have replaced.
+func TestDrawText(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := &Conn{conn: client, dead: make(chan struct{})}
+	got := make(chan []byte, 1)
+	go func() {
+		req := make([]byte, 24)
+		io.ReadFull(server, req)
+		got <- req
+	}()
+
+	if err := c.DrawText(1, 2, 10, 20, "aä😀"); err != nil {
+		t.Fatal(err)
+	}
+	req := <-got
+	if req[0] != 75 || order.Uint16(req[2:]) != 6 {
+		t.Fatalf("request %v, length %v; want PolyText16, 6", req[0], order.Uint16(req[2:]))
+	}
+	want := []byte{3, 0, 0, 'a', 0, 0xe4, 0, '?'}
+	if item := req[16:24]; string(item) != string(want) {
+		t.Errorf("text item %v, want %v", item, want)
+	}
+}
@@ -0,0 +1,144 @@
+package x11
+
+// NewID returns an unused resource ID, for a window, GC or font.
+func (c *Conn) NewID() uint32 {
+	c.idmu.Lock()
+	defer c.idmu.Unlock()
+	// the lowest bit of the mask is the step
+	c.nextID += c.idMask & -c.idMask
+	return c.idBase | c.nextID
+}
+
+// CreateWindow creates an unmapped window on the root window, filled
+// with background. It's override-redirect, so the window manager
+// leaves it alone, as a HUD or popup wants.
+func (c *Conn) CreateWindow(id uint32, x, y, width, height int, background uint32) error {
+	req := make([]byte, 40)
+	req[0] = 1
+	// the depth of the parent
+	req[1] = 0
+	order.PutUint32(req[4:], id)
+	order.PutUint32(req[8:], c.Root)
+	order.PutUint16(req[12:], uint16(int16(x)))
+	order.PutUint16(req[14:], uint16(int16(y)))
+	order.PutUint16(req[16:], uint16(width))
+	order.PutUint16(req[18:], uint16(height))
+	// no border, InputOutput, the parent's visual
+	order.PutUint16(req[22:], 1)
+	// CWBackPixel and CWOverrideRedirect
+	order.PutUint32(req[28:], 0x2|0x200)
+	order.PutUint32(req[32:], background)
+	order.PutUint32(req[36:], 1)
+	return c.Send(req)
+}
+
+func (c *Conn) MapWindow(id uint32) error {
+	req := make([]byte, 8)
+	req[0] = 8
+	order.PutUint32(req[4:], id)
+	return c.Send(req)
+}
+
+func (c *Conn) UnmapWindow(id uint32) error {
+	req := make([]byte, 8)
+	req[0] = 10
+	order.PutUint32(req[4:], id)
+	return c.Send(req)
+}
+
+// MoveResizeWindow puts a window at x, y with the given size, and
+// raises it above its siblings.
+func (c *Conn) MoveResizeWindow(id uint32, x, y, width, height int) error {
+	// x, y, width, height and stack mode, Above being 0
+	return c.configure(id, 1|2|4|8|0x40, x, y, width, height, 0)
+}
+
+// ClearWindow fills a window with its background.
+func (c *Conn) ClearWindow(id uint32) error {
+	// ClearArea, with a size of 0 meaning all of it
+	req := make([]byte, 16)
+	req[0] = 61
+	order.PutUint32(req[4:], id)
+	return c.Send(req)
+}
+
+// OpenFont loads a core font by its XLFD name. A missing font is only
+// reported by the next Sync.
+func (c *Conn) OpenFont(id uint32, name string) error {
+	req := make([]byte, 12, 12+len(name))
+	req[0] = 45
+	order.PutUint32(req[4:], id)
+	order.PutUint16(req[8:], uint16(len(name)))
+	req = append(req, name...)
+	return c.Send(req)
+}
+
+// CreateGC creates a graphics context for drawing on a window, with a
+// font for its text. A font of 0 keeps the server's default.
+func (c *Conn) CreateGC(id, drawable, font uint32) error {
+	req := make([]byte, 16, 20)
+	req[0] = 55
+	order.PutUint32(req[4:], id)
+	order.PutUint32(req[8:], drawable)
+	if font != 0 {
+		// GCFont
+		order.PutUint32(req[12:], 0x4000)
+		req = append(req, 0, 0, 0, 0)
+		order.PutUint32(req[16:], font)
+	}
+	return c.Send(req)
+}
+
+// SetForeground sets the color a GC fills and draws text with.
+func (c *Conn) SetForeground(gc, pixel uint32) error {
+	req := make([]byte, 16)
+	req[0] = 56
+	order.PutUint32(req[4:], gc)
+	// GCForeground
+	order.PutUint32(req[8:], 0x4)
+	order.PutUint32(req[12:], pixel)
+	return c.Send(req)
+}
+
+// FillRectangle fills a rectangle with the GC's foreground.
+func (c *Conn) FillRectangle(drawable, gc uint32, x, y, width, height int) error {
+	req := make([]byte, 20)
+	req[0] = 70
+	order.PutUint32(req[4:], drawable)
+	order.PutUint32(req[8:], gc)
+	order.PutUint16(req[12:], uint16(int16(x)))
+	order.PutUint16(req[14:], uint16(int16(y)))
+	order.PutUint16(req[16:], uint16(width))
+	order.PutUint16(req[18:], uint16(height))
+	return c.Send(req)
+}
+
+// DrawText draws text with its baseline starting at x, y, in the GC's
+// font and foreground. The font should be an ISO 10646 one; characters
+// past the Basic Multilingual Plane come out as question marks.
+func (c *Conn) DrawText(drawable, gc uint32, x, y int, text string) error {
+	req := make([]byte, 16)
+	req[0] = 75
+	order.PutUint32(req[4:], drawable)
+	order.PutUint32(req[8:], gc)
+	order.PutUint16(req[12:], uint16(int16(x)))
+	order.PutUint16(req[14:], uint16(int16(y)))
+	chars := []rune(text)
+	for len(chars) > 0 {
+		// a text item holds up to 254 characters
+		n := len(chars)
+		if n > 254 {
+			n = 254
+		}
+		req = append(req, byte(n), 0)
+		for _, r := range chars[:n] {
+			if r > 0xFFFF {
+				r = '?'
+			}
+			// CHAR2B is big endian
+			req = append(req, byte(r>>8), byte(r))
+		}
+		chars = chars[n:]
+	}
+	return c.Send(req)
+}
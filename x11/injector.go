@@ -0,0 +1,297 @@
+package x11
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nvlled/gosn30/modifier"
+)
+
+const (
+	keyPress      = 2
+	keyRelease    = 3
+	buttonPress   = 4
+	buttonRelease = 5
+	motionNotify  = 6
+
+	// how many unused keycodes are taken for keysyms missing from the
+	// keyboard
+	scratchKeys = 10
+)
+
+// Injector types keys and moves the pointer through the XTEST extension,
+// speaking the X protocol itself instead of going through libxdo.
+//
+// Keysyms the keyboard lacks are bound to unused keycodes. The bindings
+// are kept and reused, oldest first, rather than reverted right after
+// the key is typed, so a client reading the event late still sees the
+// right keysym.
+type Injector struct {
+	conn    *Conn
+	xtest   byte
+	xkb     byte
+	mapping [][]uint32
+	scratch []byte
+	next    int
+	// the locked keyboard group, whose keysyms the keys give
+	group int
+	// the window keys and clicks are sent to, 0 for the focused one
+	target uint32
+
+	modifier.State
+	// failing is set while requests fail, so an error is only reported
+	// once
+	failing bool
+
+	KeyDelay int
+	// OnError is told when input can't be sent, once until it works
+	// again.
+	OnError func(error)
+}
+
+// Open connects to the display in DISPLAY.
+func Open() (*Injector, error) {
+	conn, err := Dial()
+	if err != nil {
+		return nil, err
+	}
+	x := &Injector{conn: conn, KeyDelay: 12000}
+	if x.xtest, err = conn.QueryExtension("XTEST"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if x.mapping, err = conn.KeyboardMapping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for i := len(x.mapping) - 1; i >= 0 && len(x.scratch) < scratchKeys; i-- {
+		if isUnused(x.mapping[i]) {
+			x.scratch = append(x.scratch, conn.MinKeycode+byte(i))
+		}
+	}
+	if xkb, err := conn.QueryExtension("XKEYBOARD"); err == nil {
+		// UseExtension 1.0
+		if data, err := conn.Call([]byte{xkb, 0, 0, 0, 1, 0, 0, 0}); err == nil && data[1] != 0 {
+			x.xkb = xkb
+		}
+	}
+	return x, nil
+}
+
+func (x *Injector) Close() error {
+	return x.conn.Close()
+}
+
+// Screen returns the size of the first screen.
+func (x *Injector) Screen() (int, int) {
+	return x.conn.Width, x.conn.Height
+}
+
+func isUnused(keysyms []uint32) bool {
+	for _, ks := range keysyms {
+		if ks != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// report passes an error on to OnError, once until sending works again.
+func (x *Injector) report(err error) {
+	if err == nil {
+		x.failing = false
+	} else if !x.failing {
+		x.failing = true
+		if x.OnError != nil {
+			x.OnError(err)
+		}
+	}
+}
+
+func (x *Injector) fakeInput(typ, detail byte, root uint32, rootX, rootY int) error {
+	req := make([]byte, 36)
+	req[0] = x.xtest
+	req[1] = 2
+	req[4] = typ
+	req[5] = detail
+	order.PutUint32(req[12:], root)
+	order.PutUint16(req[24:], uint16(int16(rootX)))
+	order.PutUint16(req[26:], uint16(int16(rootY)))
+	return x.conn.Send(req)
+}
+
+// groupColumn returns the column of the first level of a group in a
+// key's core mapping, which has two columns for each group. Keys
+// without that group give the first group's keysyms.
+func groupColumn(syms []uint32, group int) int {
+	col := 2 * group
+	if col+1 < len(syms) && (syms[col] != 0 || syms[col+1] != 0) {
+		return col
+	}
+	return 0
+}
+
+// keycode finds the key of a keysym in the locked group, and whether it
+// needs shift, binding it to a scratch key if the keyboard doesn't have
+// it.
+func (x *Injector) keycode(keysym uint32) (byte, bool, error) {
+	for i, syms := range x.mapping {
+		base := groupColumn(syms, x.group)
+		for col := base; col < base+2 && col < len(syms); col++ {
+			if syms[col] == keysym {
+				return x.conn.MinKeycode + byte(i), col == base+1, nil
+			}
+		}
+	}
+	if len(x.scratch) == 0 {
+		return 0, false, fmt.Errorf("no keycode for keysym %#x", keysym)
+	}
+	code := x.scratch[x.next]
+	x.next = (x.next + 1) % len(x.scratch)
+	syms := x.mapping[code-x.conn.MinKeycode]
+	for i := range syms {
+		syms[i] = 0
+	}
+	syms[0] = keysym
+	if err := x.conn.ChangeKeyboardMapping(code, []uint32{keysym, keysym}); err != nil {
+		return 0, false, err
+	}
+	return code, false, x.conn.Sync()
+}
+
+func (x *Injector) pause() {
+	time.Sleep(time.Duration(x.KeyDelay) * time.Microsecond)
+}
+
+// press types keys together, releasing them in reverse.
+func (x *Injector) press(codes []byte) error {
+	if x.target != 0 {
+		return x.sendKeys(codes)
+	}
+	var err error
+	for _, code := range codes {
+		if ferr := x.fakeInput(keyPress, code, 0, 0, 0); err == nil {
+			err = ferr
+		}
+	}
+	for i := len(codes) - 1; i >= 0; i-- {
+		if ferr := x.fakeInput(keyRelease, codes[i], 0, 0, 0); err == nil {
+			err = ferr
+		}
+	}
+	x.pause()
+	return err
+}
+
+// keys returns the keycodes typing a list of keysyms together.
+func (x *Injector) keys(syms []uint32) ([]byte, error) {
+	var codes []byte
+	for _, ks := range syms {
+		code, shift, err := x.keycode(ks)
+		if err != nil {
+			return nil, err
+		}
+		if shift {
+			shiftCode, _, err := x.keycode(keysyms["Shift_L"])
+			if err != nil {
+				return nil, err
+			}
+			codes = append(codes, shiftCode)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// KeyPress types a keysym, or a combination like "Control_L+c".
+func (x *Injector) KeyPress(keyseq string) {
+	x.report(x.keyPress(x.Apply(keyseq)))
+}
+
+func (x *Injector) keyPress(keyseq string) error {
+	var syms []uint32
+	for _, name := range strings.Split(keyseq, "+") {
+		ks, ok := Keysym(name)
+		if !ok {
+			return fmt.Errorf("unknown keysym %v", name)
+		}
+		syms = append(syms, ks)
+	}
+	codes, err := x.keys(syms)
+	if err != nil {
+		return err
+	}
+	return x.press(codes)
+}
+
+func (x *Injector) EnterText(text string) {
+	var err error
+	for _, r := range text {
+		codes, kerr := x.keys([]uint32{RuneKeysym(r)})
+		if kerr == nil {
+			kerr = x.press(codes)
+		}
+		if err == nil {
+			err = kerr
+		}
+	}
+	x.report(err)
+}
+
+// SetKeyboardGroup locks the keyboard group through XKB, and reads the
+// keyboard mapping again, since keysyms are looked up in that group and
+// switching layouts may have changed it.
+func (x *Injector) SetKeyboardGroup(group int) {
+	if x.xkb == 0 {
+		return
+	}
+	// LatchLockState on the core keyboard, locking only the group
+	req := make([]byte, 16)
+	req[0] = x.xkb
+	req[1] = 5
+	order.PutUint16(req[4:], 0x100)
+	req[8] = 1
+	req[9] = byte(group)
+	x.group = group
+	if err := x.conn.Send(req); err != nil {
+		x.report(err)
+		return
+	}
+
+	mapping, err := x.conn.KeyboardMapping()
+	if err == nil {
+		x.mapping = mapping
+	}
+	x.report(err)
+}
+
+func (x *Injector) MouseMove(dx, dy int) {
+	x.report(x.fakeInput(motionNotify, 1, 0, dx, dy))
+}
+
+// MouseMoveTo warps the pointer to a position on the first screen.
+func (x *Injector) MouseMoveTo(px, py int) {
+	x.report(x.fakeInput(motionNotify, 0, x.conn.Root, px, py))
+}
+
+func (x *Injector) MouseDown(mouseButton int) {
+	if x.target != 0 {
+		x.report(x.sendInput(buttonPress, byte(mouseButton), 0))
+		return
+	}
+	x.report(x.fakeInput(buttonPress, byte(mouseButton), 0, 0, 0))
+}
+
+func (x *Injector) MouseUp(mouseButton int) {
+	if x.target != 0 {
+		x.report(x.sendInput(buttonRelease, byte(mouseButton), 0))
+		return
+	}
+	x.report(x.fakeInput(buttonRelease, byte(mouseButton), 0, 0, 0))
+}
+
+func (x *Injector) MouseClick(mouseButton int) {
+	x.MouseDown(mouseButton)
+	x.MouseUp(mouseButton)
+}
@@ -0,0 +1,80 @@
+package x11
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// startXvfb runs a virtual X server and points DISPLAY at it.
+func startXvfb(t *testing.T) {
+	t.Helper()
+	xvfb, err := exec.LookPath("Xvfb")
+	if err != nil {
+		t.Skip("Xvfb not found")
+	}
+	number := 90 + os.Getpid()%100
+	display := fmt.Sprintf(":%v", number)
+	cmd := exec.Command(xvfb, display, "-nolisten", "tcp")
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	socket := fmt.Sprintf("/tmp/.X11-unix/X%v", number)
+	for i := 0; ; i++ {
+		if _, err := os.Stat(socket); err == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatal("Xvfb didn't start")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	old := os.Getenv("DISPLAY")
+	t.Cleanup(func() { os.Setenv("DISPLAY", old) })
+	os.Setenv("DISPLAY", display)
+}
+
+func TestInjector(t *testing.T) {
+	startXvfb(t)
+	x, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	x.KeyDelay = 0
+
+	x.MouseMoveTo(100, 50)
+	x.MouseMove(5, -10)
+	if px, py, err := x.conn.QueryPointer(); err != nil || px != 105 || py != 40 {
+		t.Errorf("pointer at %v,%v (%v), want 105,40", px, py, err)
+	}
+
+	for _, name := range []string{"a", "A", "Return", "Control_L"} {
+		ks, _ := Keysym(name)
+		if _, err := x.keys([]uint32{ks}); err != nil {
+			t.Errorf("%v: %v", name, err)
+		}
+	}
+
+	// not on the default keyboard, so it's bound to a spare keycode
+	x.EnterText("ä")
+	mapping, err := x.conn.KeyboardMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, syms := range mapping {
+		for _, ks := range syms {
+			found = found || ks == RuneKeysym('ä')
+		}
+	}
+	if !found {
+		t.Error("ä wasn't bound to a keycode")
+	}
+}
@@ -0,0 +1,117 @@
+package x11
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// keysyms are the values of the named keysyms gosn30 uses, from
+// X11/keysymdef.h. Single characters don't need a name.
+var keysyms = map[string]uint32{
+	"BackSpace":        0xff08,
+	"Tab":              0xff09,
+	"Return":           0xff0d,
+	"Pause":            0xff13,
+	"Scroll_Lock":      0xff14,
+	"Sys_Req":          0xff15,
+	"Escape":           0xff1b,
+	"Delete":           0xffff,
+	"Home":             0xff50,
+	"Left":             0xff51,
+	"Up":               0xff52,
+	"Right":            0xff53,
+	"Down":             0xff54,
+	"Prior":            0xff55,
+	"Next":             0xff56,
+	"End":              0xff57,
+	"Print":            0xff61,
+	"Insert":           0xff63,
+	"Menu":             0xff67,
+	"Num_Lock":         0xff7f,
+	"KP_Enter":         0xff8d,
+	"KP_Multiply":      0xffaa,
+	"KP_Add":           0xffab,
+	"KP_Subtract":      0xffad,
+	"KP_Decimal":       0xffae,
+	"KP_Divide":        0xffaf,
+	"Shift_L":          0xffe1,
+	"Shift_R":          0xffe2,
+	"Control_L":        0xffe3,
+	"Control_R":        0xffe4,
+	"Caps_Lock":        0xffe5,
+	"Alt_L":            0xffe9,
+	"Alt_R":            0xffea,
+	"Super_L":          0xffeb,
+	"Super_R":          0xffec,
+	"ISO_Level3_Shift": 0xfe03,
+	"ISO_Left_Tab":     0xfe20,
+
+	"space":        ' ',
+	"exclam":       '!',
+	"quotedbl":     '"',
+	"numbersign":   '#',
+	"dollar":       '$',
+	"percent":      '%',
+	"ampersand":    '&',
+	"apostrophe":   '\'',
+	"parenleft":    '(',
+	"parenright":   ')',
+	"asterisk":     '*',
+	"plus":         '+',
+	"comma":        ',',
+	"minus":        '-',
+	"period":       '.',
+	"slash":        '/',
+	"colon":        ':',
+	"semicolon":    ';',
+	"less":         '<',
+	"equal":        '=',
+	"greater":      '>',
+	"question":     '?',
+	"at":           '@',
+	"bracketleft":  '[',
+	"backslash":    '\\',
+	"bracketright": ']',
+	"asciicircum":  '^',
+	"underscore":   '_',
+	"grave":        '`',
+	"braceleft":    '{',
+	"bar":          '|',
+	"braceright":   '}',
+	"asciitilde":   '~',
+}
+
+func init() {
+	for i := 0; i < 10; i++ {
+		keysyms["KP_"+strconv.Itoa(i)] = 0xffb0 + uint32(i)
+	}
+	for i := 0; i < 12; i++ {
+		keysyms["F"+strconv.Itoa(i+1)] = 0xffbe + uint32(i)
+	}
+}
+
+// Keysym returns the keysym of a name or a single character.
+func Keysym(name string) (uint32, bool) {
+	if ks, ok := keysyms[name]; ok {
+		return ks, true
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	if size == 0 || size != len(name) || r == utf8.RuneError {
+		return 0, false
+	}
+	return RuneKeysym(r), true
+}
+
+// RuneKeysym returns the keysym of a character: Latin-1 characters are
+// their own keysyms and the rest are offset into the Unicode range.
+func RuneKeysym(r rune) uint32 {
+	switch {
+	case r == '\n':
+		return keysyms["Return"]
+	case r == '\t':
+		return keysyms["Tab"]
+	case r >= 0x20 && r <= 0x7e, r >= 0xa0 && r <= 0xff:
+		return uint32(r)
+	}
+	return 0x1000000 + uint32(r)
+}
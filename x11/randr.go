@@ -0,0 +1,68 @@
+package x11
+
+import (
+	"errors"
+)
+
+// Monitor is the part of the screen an output shows, as XRandR sees it.
+type Monitor struct {
+	Name          string
+	X, Y          int
+	Width, Height int
+	Primary       bool
+}
+
+// Monitors returns the active monitors, in the server's order. Without
+// RandR 1.5 the whole screen is one monitor.
+func (c *Conn) Monitors() ([]Monitor, error) {
+	screen := []Monitor{{Name: "screen", Width: c.Width, Height: c.Height, Primary: true}}
+	randr, err := c.QueryExtension("RANDR")
+	if err != nil {
+		return screen, nil
+	}
+	// QueryVersion 1.5, the first with monitors
+	req := make([]byte, 12)
+	req[0], req[1] = randr, 0
+	order.PutUint32(req[4:], 1)
+	order.PutUint32(req[8:], 5)
+	data, err := c.Call(req)
+	if err != nil {
+		return nil, err
+	}
+	if major, minor := order.Uint32(data[8:]), order.Uint32(data[12:]); major < 1 || (major == 1 && minor < 5) {
+		return screen, nil
+	}
+
+	// GetMonitors, active ones only
+	req = make([]byte, 12)
+	req[0], req[1] = randr, 42
+	order.PutUint32(req[4:], c.Root)
+	req[8] = 1
+	if data, err = c.Call(req); err != nil {
+		return nil, err
+	}
+	count := int(order.Uint32(data[12:]))
+	monitors := make([]Monitor, 0, count)
+	off := 32
+	for i := 0; i < count; i++ {
+		if off+24 > len(data) {
+			return nil, errors.New("short GetMonitors reply")
+		}
+		m := Monitor{
+			Primary: data[off+4] != 0,
+			X:       int(int16(order.Uint16(data[off+8:]))),
+			Y:       int(int16(order.Uint16(data[off+10:]))),
+			Width:   int(order.Uint16(data[off+12:])),
+			Height:  int(order.Uint16(data[off+14:])),
+		}
+		if name, err := c.AtomName(order.Uint32(data[off:])); err == nil {
+			m.Name = name
+		}
+		monitors = append(monitors, m)
+		off += 24 + 4*int(order.Uint16(data[off+6:]))
+	}
+	if len(monitors) == 0 {
+		return screen, nil
+	}
+	return monitors, nil
+}
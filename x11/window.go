@@ -0,0 +1,124 @@
+package x11
+
+import (
+	"strings"
+)
+
+// Window describes a top level window, for picking a profile.
+type Window struct {
+	ID uint32
+	// Instance and Class are the two parts of WM_CLASS.
+	Instance   string
+	Class      string
+	Title      string
+	Fullscreen bool
+	// PID is the process that owns the window, 0 if it's unknown.
+	PID int
+}
+
+// atoms interns names, remembering them since atoms never change.
+func (c *Conn) atoms(names ...string) ([]uint32, error) {
+	c.amu.Lock()
+	defer c.amu.Unlock()
+	if c.atomCache == nil {
+		c.atomCache = make(map[string]uint32)
+	}
+	atoms := make([]uint32, len(names))
+	for i, name := range names {
+		atom, ok := c.atomCache[name]
+		if !ok {
+			var err error
+			if atom, err = c.InternAtom(name); err != nil {
+				return nil, err
+			}
+			c.atomCache[name] = atom
+		}
+		atoms[i] = atom
+	}
+	return atoms, nil
+}
+
+// ActiveWindow returns the window the window manager says is active. Its
+// ID is 0 when there's none.
+func (c *Conn) ActiveWindow() (Window, error) {
+	atoms, err := c.atoms("_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return Window{}, err
+	}
+	value, format, err := c.Property(c.Root, atoms[0])
+	if err != nil || format != 32 || len(value) < 4 {
+		return Window{}, err
+	}
+	if id := order.Uint32(value); id != 0 {
+		return c.Window(id)
+	}
+	return Window{}, nil
+}
+
+// Windows returns the windows the window manager manages, oldest first.
+func (c *Conn) Windows() ([]Window, error) {
+	atoms, err := c.atoms("_NET_CLIENT_LIST")
+	if err != nil {
+		return nil, err
+	}
+	value, format, err := c.Property(c.Root, atoms[0])
+	if err != nil || format != 32 {
+		return nil, err
+	}
+	var windows []Window
+	for i := 0; i+4 <= len(value); i += 4 {
+		w, err := c.Window(order.Uint32(value[i:]))
+		if err != nil {
+			// windows close while they're read
+			continue
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// Window describes the window with the given ID.
+func (c *Conn) Window(id uint32) (Window, error) {
+	atoms, err := c.atoms("WM_CLASS", "_NET_WM_NAME", "WM_NAME",
+		"_NET_WM_STATE", "_NET_WM_STATE_FULLSCREEN", "_NET_WM_PID")
+	if err != nil {
+		return Window{}, err
+	}
+	class, netName, name, state, fullscreen, pid := atoms[0], atoms[1], atoms[2], atoms[3], atoms[4], atoms[5]
+
+	w := Window{ID: id}
+	value, format, err := c.Property(w.ID, class)
+	if err != nil {
+		return w, err
+	}
+	parts := strings.Split(string(value), "\x00")
+	w.Instance = parts[0]
+	if len(parts) > 1 {
+		w.Class = parts[1]
+	}
+
+	if value, _, err = c.Property(w.ID, netName); err == nil && len(value) == 0 {
+		value, _, err = c.Property(w.ID, name)
+	}
+	if err != nil {
+		return w, err
+	}
+	w.Title = string(value)
+
+	if value, format, err = c.Property(w.ID, state); err != nil {
+		return w, err
+	}
+	for i := 0; format == 32 && i+4 <= len(value); i += 4 {
+		if order.Uint32(value[i:]) == fullscreen {
+			w.Fullscreen = true
+		}
+	}
+
+	if value, format, err = c.Property(w.ID, pid); err != nil {
+		return w, err
+	}
+	if format == 32 && len(value) >= 4 {
+		w.PID = int(order.Uint32(value))
+	}
+	return w, nil
+}
@@ -0,0 +1,373 @@
+package x11
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// sticky windows are on every desktop
+	allDesktops = 0xFFFFFFFF
+
+	clientMessage = 33
+
+	substructureNotifyMask   = 1 << 19
+	substructureRedirectMask = 1 << 20
+)
+
+// Search describes the windows to look for. Name, Class and ClassName
+// are regular expressions matched against the title and the two parts
+// of WM_CLASS. Empty fields and a PID of 0 match any window.
+type Search struct {
+	Name        string
+	Class       string
+	ClassName   string
+	PID         int
+	OnlyVisible bool
+}
+
+func (s Search) String() string {
+	var parts []string
+	if s.Name != "" {
+		parts = append(parts, "name="+s.Name)
+	}
+	if s.Class != "" {
+		parts = append(parts, "class="+s.Class)
+	}
+	if s.ClassName != "" {
+		parts = append(parts, "classname="+s.ClassName)
+	}
+	if s.PID != 0 {
+		parts = append(parts, "pid="+strconv.Itoa(s.PID))
+	}
+	return strings.Join(parts, " ")
+}
+
+// SendEvent sends an event to a window, to the clients listening for
+// mask on it.
+func (c *Conn) SendEvent(window, mask uint32, event []byte) error {
+	req := make([]byte, 12, 44)
+	req[0] = 25
+	order.PutUint32(req[4:], window)
+	order.PutUint32(req[8:], mask)
+	req = append(req, event...)
+	return c.Send(req)
+}
+
+// clientMessage asks the window manager to do something with a window,
+// the EWMH way.
+func (c *Conn) clientMessage(window uint32, typ string, data ...uint32) error {
+	atoms, err := c.atoms(typ)
+	if err != nil {
+		return err
+	}
+	ev := make([]byte, 32)
+	ev[0], ev[1] = clientMessage, 32
+	order.PutUint32(ev[4:], window)
+	order.PutUint32(ev[8:], atoms[0])
+	for i, d := range data {
+		order.PutUint32(ev[12+4*i:], d)
+	}
+	return c.SendEvent(c.Root, substructureRedirectMask|substructureNotifyMask, ev)
+}
+
+// cardinal returns a 32 bit property holding a single number.
+func (c *Conn) cardinal(window uint32, name string) (uint32, bool) {
+	atoms, err := c.atoms(name)
+	if err != nil {
+		return 0, false
+	}
+	value, format, err := c.Property(window, atoms[0])
+	if err != nil || format != 32 || len(value) < 4 {
+		return 0, false
+	}
+	return order.Uint32(value), true
+}
+
+// clientList returns the IDs of the windows the window manager manages,
+// oldest first.
+func (c *Conn) clientList() []uint32 {
+	atoms, err := c.atoms("_NET_CLIENT_LIST")
+	if err != nil {
+		return nil
+	}
+	value, format, err := c.Property(c.Root, atoms[0])
+	if err != nil || format != 32 {
+		return nil
+	}
+	var ids []uint32
+	for i := 0; i+4 <= len(value); i += 4 {
+		ids = append(ids, order.Uint32(value[i:]))
+	}
+	return ids
+}
+
+// position returns where a window is on the root window.
+func (c *Conn) position(window uint32) (int, int, error) {
+	// TranslateCoordinates of its top left corner
+	req := make([]byte, 16)
+	req[0] = 40
+	order.PutUint32(req[4:], window)
+	order.PutUint32(req[8:], c.Root)
+	data, err := c.Call(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(int16(order.Uint16(data[12:]))), int(int16(order.Uint16(data[14:]))), nil
+}
+
+// size returns how wide and high a window is.
+func (c *Conn) size(window uint32) (int, int, error) {
+	// GetGeometry
+	req := make([]byte, 8)
+	req[0] = 14
+	order.PutUint32(req[4:], window)
+	data, err := c.Call(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(order.Uint16(data[16:])), int(order.Uint16(data[18:])), nil
+}
+
+// configure sets the position or size of a window, mask saying which:
+// x 1, y 2, width 4 and height 8, with the values in that order.
+func (c *Conn) configure(window uint32, mask uint16, values ...int) error {
+	req := make([]byte, 12, 12+4*len(values))
+	req[0] = 12
+	order.PutUint32(req[4:], window)
+	order.PutUint16(req[8:], mask)
+	for _, v := range values {
+		req = append(req, 0, 0, 0, 0)
+		order.PutUint32(req[len(req)-4:], uint32(int32(v)))
+	}
+	return c.Send(req)
+}
+
+// viewable reports whether a window and its parents are mapped.
+func (c *Conn) viewable(window uint32) bool {
+	// GetWindowAttributes
+	req := make([]byte, 8)
+	req[0] = 3
+	order.PutUint32(req[4:], window)
+	data, err := c.Call(req)
+	return err == nil && data[26] == 2
+}
+
+// ActiveWindow returns the window the window manager says is active.
+func (x *Injector) ActiveWindow() (uint32, bool) {
+	w, ok := x.conn.cardinal(x.conn.Root, "_NET_ACTIVE_WINDOW")
+	return w, ok && w != 0
+}
+
+// Windows returns the windows on the current desktop, oldest first.
+func (x *Injector) Windows() []uint32 {
+	current, _ := x.Desktop()
+	var windows []uint32
+	for _, w := range x.conn.clientList() {
+		if d := x.WindowDesktop(w); d == current || d == allDesktops || d < 0 {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// FindWindow returns the first window matching s, of those the window
+// manager manages.
+func (x *Injector) FindWindow(s Search) (uint32, bool) {
+	var patterns [3]*regexp.Regexp
+	for i, expr := range []string{s.Name, s.Class, s.ClassName} {
+		if expr == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			x.report(err)
+			return 0, false
+		}
+		patterns[i] = re
+	}
+	for _, id := range x.conn.clientList() {
+		w, err := x.conn.Window(id)
+		if err != nil {
+			// windows close while they're read
+			continue
+		}
+		matches := true
+		for i, field := range []string{w.Title, w.Class, w.Instance} {
+			if patterns[i] != nil && !patterns[i].MatchString(field) {
+				matches = false
+			}
+		}
+		if s.PID != 0 && w.PID != s.PID {
+			matches = false
+		}
+		if matches && (!s.OnlyVisible || x.conn.viewable(id)) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// WindowName returns the title of a window.
+func (x *Injector) WindowName(w uint32) string {
+	info, _ := x.conn.Window(w)
+	return info.Title
+}
+
+// ActivateWindow focuses a window and raises it, switching to its
+// desktop if needed.
+func (x *Injector) ActivateWindow(w uint32) {
+	if d := x.WindowDesktop(w); d >= 0 && d != allDesktops {
+		if current, _ := x.Desktop(); d != current {
+			x.SetDesktop(d)
+		}
+	}
+	// 2 says it's from a pager, which window managers don't second guess
+	x.report(x.conn.clientMessage(w, "_NET_ACTIVE_WINDOW", 2, 0, 0))
+}
+
+func (x *Injector) MinimizeWindow(w uint32) {
+	// IconicState
+	x.report(x.conn.clientMessage(w, "WM_CHANGE_STATE", 3))
+}
+
+// MoveWindowBy moves a window by dx, dy pixels.
+func (x *Injector) MoveWindowBy(w uint32, dx, dy int) {
+	px, py, err := x.conn.position(w)
+	if err != nil {
+		x.report(err)
+		return
+	}
+	x.report(x.conn.configure(w, 1|2, px+dx, py+dy))
+}
+
+// ResizeWindowBy makes a window dw pixels wider and dh pixels higher.
+func (x *Injector) ResizeWindowBy(w uint32, dw, dh int) {
+	width, height, err := x.conn.size(w)
+	if err != nil {
+		x.report(err)
+		return
+	}
+	nw, nh := width+dw, height+dh
+	if nw < 1 || nh < 1 {
+		return
+	}
+	x.report(x.conn.configure(w, 4|8, nw, nh))
+}
+
+// ToggleMaximized maximizes a window, or restores it if it's
+// maximized.
+func (x *Injector) ToggleMaximized(w uint32) {
+	atoms, err := x.conn.atoms("_NET_WM_STATE_MAXIMIZED_VERT", "_NET_WM_STATE_MAXIMIZED_HORZ")
+	if err != nil {
+		x.report(err)
+		return
+	}
+	// _NET_WM_STATE_TOGGLE is 2, and 1 says it's from an application
+	x.report(x.conn.clientMessage(w, "_NET_WM_STATE", 2, atoms[0], atoms[1], 1))
+}
+
+// CloseWindow asks the window manager to close a window, as its close
+// button would.
+func (x *Injector) CloseWindow(w uint32) {
+	x.report(x.conn.clientMessage(w, "_NET_CLOSE_WINDOW", 0, 1))
+}
+
+// Desktop returns the current desktop and how many there are.
+func (x *Injector) Desktop() (int, int) {
+	current, _ := x.conn.cardinal(x.conn.Root, "_NET_CURRENT_DESKTOP")
+	count, _ := x.conn.cardinal(x.conn.Root, "_NET_NUMBER_OF_DESKTOPS")
+	return int(current), int(count)
+}
+
+func (x *Injector) SetDesktop(desktop int) {
+	x.report(x.conn.clientMessage(x.conn.Root, "_NET_CURRENT_DESKTOP", uint32(desktop), 0))
+}
+
+// WindowDesktop returns the desktop a window is on, or -1 if it's
+// unknown.
+func (x *Injector) WindowDesktop(w uint32) int {
+	desktop, ok := x.conn.cardinal(w, "_NET_WM_DESKTOP")
+	if !ok {
+		return -1
+	}
+	return int(desktop)
+}
+
+func (x *Injector) SetWindowDesktop(w uint32, desktop int) {
+	// 2 says it's from a pager
+	x.report(x.conn.clientMessage(w, "_NET_WM_DESKTOP", uint32(desktop), 2))
+}
+
+// Target returns the window keys and clicks are sent to, 0 being the
+// focused one.
+func (x *Injector) Target() uint32 {
+	return x.target
+}
+
+// SetTarget sends keys and clicks to a window, whether or not it has
+// focus. Not every program accepts input sent this way.
+func (x *Injector) SetTarget(w uint32) {
+	x.target = w
+}
+
+// sendInput sends a key or button event straight to the target window,
+// instead of faking it through XTEST.
+func (x *Injector) sendInput(typ, detail byte, state uint16) error {
+	ev := make([]byte, 32)
+	ev[0], ev[1] = typ, detail
+	order.PutUint32(ev[8:], x.conn.Root)
+	order.PutUint32(ev[12:], x.target)
+	order.PutUint16(ev[28:], state)
+	// same screen
+	ev[30] = 1
+	// KeyPressMask, KeyReleaseMask, ButtonPressMask or ButtonReleaseMask
+	return x.conn.SendEvent(x.target, 1<<(typ-keyPress), ev)
+}
+
+// modifierMask returns the state bit a key sets while it's held, 0 if
+// it isn't a modifier.
+func (x *Injector) modifierMask(code byte) uint16 {
+	syms := x.mapping[code-x.conn.MinKeycode]
+	if len(syms) == 0 {
+		return 0
+	}
+	for _, m := range []struct {
+		names []string
+		mask  uint16
+	}{
+		{[]string{"Shift_L", "Shift_R"}, 1},
+		{[]string{"Control_L", "Control_R"}, 4},
+		{[]string{"Alt_L", "Alt_R", "Meta_L", "Meta_R"}, 8},
+		{[]string{"Super_L", "Super_R"}, 64},
+	} {
+		for _, name := range m.names {
+			if ks, _ := Keysym(name); ks == syms[0] {
+				return m.mask
+			}
+		}
+	}
+	return 0
+}
+
+// sendKeys types keys together into the target window, the modifiers
+// among them going into the state of the others.
+func (x *Injector) sendKeys(codes []byte) error {
+	var state uint16
+	var err error
+	for _, code := range codes {
+		if serr := x.sendInput(keyPress, code, state); err == nil {
+			err = serr
+		}
+		state |= x.modifierMask(code)
+	}
+	for i := len(codes) - 1; i >= 0; i-- {
+		if serr := x.sendInput(keyRelease, codes[i], state); err == nil {
+			err = serr
+		}
+		state &^= x.modifierMask(codes[i])
+	}
+	x.pause()
+	return err
+}
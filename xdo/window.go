@@ -0,0 +1,142 @@
+//go:build xdo
+// +build xdo
+
+package xdo
+
+// #include <stdlib.h>
+// #include <xdo.h>
+import "C"
+import (
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Search describes the windows to look for. Name, Class and ClassName
+// are regular expressions matched against the title and the two parts
+// of WM_CLASS. Empty fields and a PID of 0 match any window.
+type Search struct {
+	Name        string
+	Class       string
+	ClassName   string
+	PID         int
+	OnlyVisible bool
+}
+
+func (s Search) String() string {
+	var parts []string
+	if s.Name != "" {
+		parts = append(parts, "name="+s.Name)
+	}
+	if s.Class != "" {
+		parts = append(parts, "class="+s.Class)
+	}
+	if s.ClassName != "" {
+		parts = append(parts, "classname="+s.ClassName)
+	}
+	if s.PID != 0 {
+		parts = append(parts, "pid="+strconv.Itoa(s.PID))
+	}
+	return strings.Join(parts, " ")
+}
+
+// SearchWindows returns the windows matching every field of s.
+func (t *Xdo) SearchWindows(s Search) []Window {
+	var search C.xdo_search_t
+	search.max_depth = -1
+	search.require = C.SEARCH_ALL
+	setString := func(field **C.char, value string, mask C.uint) {
+		if value == "" {
+			return
+		}
+		*field = C.CString(value)
+		search.searchmask |= mask
+	}
+	setString(&search.winname, s.Name, C.SEARCH_NAME)
+	setString(&search.winclass, s.Class, C.SEARCH_CLASS)
+	setString(&search.winclassname, s.ClassName, C.SEARCH_CLASSNAME)
+	defer C.free(unsafe.Pointer(search.winname))
+	defer C.free(unsafe.Pointer(search.winclass))
+	defer C.free(unsafe.Pointer(search.winclassname))
+	if s.PID != 0 {
+		search.pid = C.int(s.PID)
+		search.searchmask |= C.SEARCH_PID
+	}
+	if s.OnlyVisible {
+		search.only_visible = 1
+		search.searchmask |= C.SEARCH_ONLYVISIBLE
+	}
+
+	var list *C.Window
+	var count C.uint
+	C.xdo_search_windows(t.xdo, &search, &list, &count)
+	if list == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(list))
+	windows := make([]Window, count)
+	for i, w := range (*[1 << 20]C.Window)(unsafe.Pointer(list))[:count:count] {
+		windows[i] = Window(w)
+	}
+	return windows
+}
+
+// FindWindow returns the first window matching s.
+func (t *Xdo) FindWindow(s Search) (Window, bool) {
+	windows := t.SearchWindows(s)
+	if len(windows) == 0 {
+		return 0, false
+	}
+	return windows[0], true
+}
+
+// WindowName returns the title of a window.
+func (t *Xdo) WindowName(w Window) string {
+	var name *C.uchar
+	var length, typ C.int
+	C.xdo_get_window_name(t.xdo, C.Window(w), &name, &length, &typ)
+	if name == nil {
+		return ""
+	}
+	defer C.XFree(unsafe.Pointer(name))
+	return C.GoStringN((*C.char)(unsafe.Pointer(name)), length)
+}
+
+// WindowClass returns the class part of a window's WM_CLASS.
+func (t *Xdo) WindowClass(w Window) string {
+	prop := C.CString("WM_CLASS")
+	defer C.free(unsafe.Pointer(prop))
+	var value *C.uchar
+	var nitems C.long
+	var typ C.Atom
+	var size C.int
+	C.xdo_get_window_property(t.xdo, C.Window(w), prop, &value, &nitems, &typ, &size)
+	if value == nil {
+		return ""
+	}
+	defer C.XFree(unsafe.Pointer(value))
+	// the instance name, then the class, each ending in a NUL
+	parts := strings.Split(C.GoStringN((*C.char)(unsafe.Pointer(value)), C.int(nitems)), "\x00")
+	if len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// WindowPID returns the process that owns a window, or 0 if it's
+// unknown.
+func (t *Xdo) WindowPID(w Window) int {
+	return int(C.xdo_get_pid_window(t.xdo, C.Window(w)))
+}
+
+// Target returns the window keys and clicks are sent to, CURRENTWINDOW
+// being the focused one.
+func (t *Xdo) Target() Window {
+	return Window(t.Window)
+}
+
+// SetTarget sends keys and clicks to a window, whether or not it has
+// focus. Not every program accepts input sent this way.
+func (t *Xdo) SetTarget(w Window) {
+	t.Window = int(w)
+}
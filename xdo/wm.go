@@ -0,0 +1,147 @@
+//go:build xdo
+// +build xdo
+
+package xdo
+
+// #include <stdlib.h>
+// #include <string.h>
+// #include <xdo.h>
+//
+// // client_message asks the window manager to do something with a
+// // window, the EWMH way.
+// static void client_message(Display *dpy, Window w, Atom type, long l0, long l1, long l2, long l3) {
+// 	XEvent ev;
+// 	memset(&ev, 0, sizeof(ev));
+// 	ev.xclient.type = ClientMessage;
+// 	ev.xclient.window = w;
+// 	ev.xclient.message_type = type;
+// 	ev.xclient.format = 32;
+// 	ev.xclient.data.l[0] = l0;
+// 	ev.xclient.data.l[1] = l1;
+// 	ev.xclient.data.l[2] = l2;
+// 	ev.xclient.data.l[3] = l3;
+// 	XSendEvent(dpy, DefaultRootWindow(dpy), False,
+// 		SubstructureRedirectMask|SubstructureNotifyMask, &ev);
+// 	XFlush(dpy);
+// }
+import "C"
+import (
+	"unsafe"
+)
+
+// sticky windows are on every desktop
+const allDesktops = 0xFFFFFFFF
+
+func (t *Xdo) atom(name string) C.Atom {
+	str := C.CString(name)
+	defer C.free(unsafe.Pointer(str))
+	return C.XInternAtom(t.xdo.xdpy, str, C.False)
+}
+
+// ActiveWindow returns the window the window manager says is active.
+func (t *Xdo) ActiveWindow() (Window, bool) {
+	var w C.Window
+	if C.xdo_get_active_window(t.xdo, &w) != 0 || w == 0 {
+		return 0, false
+	}
+	return Window(w), true
+}
+
+// Windows returns the windows on the current desktop, oldest first.
+func (t *Xdo) Windows() []Window {
+	prop := C.CString("_NET_CLIENT_LIST")
+	defer C.free(unsafe.Pointer(prop))
+	var value *C.uchar
+	var nitems C.long
+	var typ C.Atom
+	var size C.int
+	root := C.XDefaultRootWindow(t.xdo.xdpy)
+	C.xdo_get_window_property(t.xdo, root, prop, &value, &nitems, &typ, &size)
+	if value == nil {
+		return nil
+	}
+	defer C.XFree(unsafe.Pointer(value))
+
+	current, _ := t.Desktop()
+	// 32 bit properties come as longs
+	list := (*[1 << 20]C.long)(unsafe.Pointer(value))[:nitems:nitems]
+	var windows []Window
+	for _, item := range list {
+		w := Window(item)
+		if d := t.WindowDesktop(w); d == current || d == allDesktops || d < 0 {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// ActivateWindow focuses a window and raises it, switching to its
+// desktop if needed.
+func (t *Xdo) ActivateWindow(w Window) {
+	C.xdo_activate_window(t.xdo, C.Window(w))
+}
+
+func (t *Xdo) MinimizeWindow(w Window) {
+	C.xdo_minimize_window(t.xdo, C.Window(w))
+}
+
+// MoveWindowBy moves a window by dx, dy pixels.
+func (t *Xdo) MoveWindowBy(w Window, dx, dy int) {
+	var x, y C.int
+	if C.xdo_get_window_location(t.xdo, C.Window(w), &x, &y, nil) != 0 {
+		return
+	}
+	C.xdo_move_window(t.xdo, C.Window(w), x+C.int(dx), y+C.int(dy))
+}
+
+// ResizeWindowBy makes a window dw pixels wider and dh pixels higher.
+func (t *Xdo) ResizeWindowBy(w Window, dw, dh int) {
+	var width, height C.uint
+	if C.xdo_get_window_size(t.xdo, C.Window(w), &width, &height) != 0 {
+		return
+	}
+	nw, nh := int(width)+dw, int(height)+dh
+	if nw < 1 || nh < 1 {
+		return
+	}
+	C.xdo_set_window_size(t.xdo, C.Window(w), C.int(nw), C.int(nh), 0)
+}
+
+// ToggleMaximized maximizes a window, or restores it if it's
+// maximized.
+func (t *Xdo) ToggleMaximized(w Window) {
+	// _NET_WM_STATE_TOGGLE is 2, and 1 says it's from an application
+	C.client_message(t.xdo.xdpy, C.Window(w), t.atom("_NET_WM_STATE"), 2,
+		C.long(t.atom("_NET_WM_STATE_MAXIMIZED_VERT")),
+		C.long(t.atom("_NET_WM_STATE_MAXIMIZED_HORZ")), 1)
+}
+
+// CloseWindow asks the window manager to close a window, as its close
+// button would.
+func (t *Xdo) CloseWindow(w Window) {
+	C.client_message(t.xdo.xdpy, C.Window(w), t.atom("_NET_CLOSE_WINDOW"), 0, 1, 0, 0)
+}
+
+// Desktop returns the current desktop and how many there are.
+func (t *Xdo) Desktop() (int, int) {
+	var current, count C.long
+	C.xdo_get_current_desktop(t.xdo, &current)
+	C.xdo_get_number_of_desktops(t.xdo, &count)
+	return int(current), int(count)
+}
+
+func (t *Xdo) SetDesktop(desktop int) {
+	C.xdo_set_current_desktop(t.xdo, C.long(desktop))
+}
+
+// WindowDesktop returns the desktop a window is on, or -1 if it's
+// unknown.
+func (t *Xdo) WindowDesktop(w Window) int {
+	desktop := C.long(-1)
+	C.xdo_get_desktop_for_window(t.xdo, C.Window(w), &desktop)
+	return int(desktop)
+}
+
+func (t *Xdo) SetWindowDesktop(w Window, desktop int) {
+	C.xdo_set_desktop_for_window(t.xdo, C.Window(w), C.long(desktop))
+}
@@ -1,13 +1,18 @@
+//go:build xdo
+// +build xdo
+
 package xdo
 
 // #include <stdlib.h>
 // #include <xdo.h>
-// #cgo LDFLAGS: -lxdo
+// #include <X11/XKBlib.h>
+// #cgo LDFLAGS: -lxdo -lX11
 import "C"
 import (
-	"strings"
-	"unicode"
+	"errors"
 	"unsafe"
+
+	"github.com/nvlled/gosn30/modifier"
 )
 
 const CURRENTWINDOW = 0
@@ -18,6 +23,8 @@ const (
 	MbRight
 	MbWheelUp
 	MbWheelDown
+	MbWheelLeft
+	MbWheelRight
 )
 
 const (
@@ -29,10 +36,8 @@ const (
 type Window int
 
 type Xdo struct {
-	xdo       *C.xdo_t
-	ctrlDown  bool
-	altDown   bool
-	shiftDown bool
+	xdo *C.xdo_t
+	modifier.State
 
 	Window   int
 	KeyDelay int
@@ -46,10 +51,31 @@ func New() *Xdo {
 	return x
 }
 
+// Open is like New but fails when there's no X display.
+func Open() (*Xdo, error) {
+	x := New()
+	if x.xdo == nil {
+		return nil, errors.New("can't open the X display")
+	}
+	return x, nil
+}
+
 func (t *Xdo) MouseMove(x, y int) {
 	C.xdo_move_mouse_relative(t.xdo, C.int(x), C.int(y))
 }
 
+// MouseMoveTo warps the pointer to a position on the first screen.
+func (t *Xdo) MouseMoveTo(x, y int) {
+	C.xdo_move_mouse(t.xdo, C.int(x), C.int(y), 0)
+}
+
+// Screen returns the size of the first screen.
+func (t *Xdo) Screen() (int, int) {
+	var width, height C.uint
+	C.xdo_get_viewport_dimensions(t.xdo, &width, &height, 0)
+	return int(width), int(height)
+}
+
 func (t *Xdo) MouseDown(mouseButton int) {
 	C.xdo_mouse_down(t.xdo, C.Window(t.Window), C.int(mouseButton))
 }
@@ -69,18 +95,7 @@ func (t *Xdo) MouseClick(mouseButton int) {
 }
 
 func (t *Xdo) KeyPress(keyseq string) {
-	if t.shiftDown && isLetter(keyseq) {
-		keyseq = strings.ToUpper(keyseq)
-	}
-	if t.ctrlDown {
-		keyseq = "Control_L+" + keyseq
-	}
-	if t.altDown {
-		keyseq = "Alt_L+" + keyseq
-	}
-	str := C.CString(keyseq)
-	//t.ctrlDown = false
-	//t.altDown = false
+	str := C.CString(t.Apply(keyseq))
 	defer C.free(unsafe.Pointer(str))
 	C.xdo_send_keysequence_window(t.xdo, C.Window(t.Window), str, C.useconds_t(t.KeyDelay))
 }
@@ -91,31 +106,9 @@ func (t *Xdo) EnterText(text string) {
 	C.xdo_enter_text_window(t.xdo, C.Window(t.Window), str, C.useconds_t(t.KeyDelay))
 }
 
-func (t *Xdo) SetCtrl(val bool) {
-	t.ctrlDown = val
-}
-func (t *Xdo) SetShift(val bool) {
-	t.shiftDown = val
-}
-func (t *Xdo) ToggleCapsLock() {
-	t.shiftDown = !t.shiftDown
-}
-func (t *Xdo) ToggleCtrl() {
-	t.ctrlDown = !t.ctrlDown
-}
-
-func (t *Xdo) ToggleAlt() {
-	t.altDown = !t.altDown
-}
-
-func (t *Xdo) IsCapsLock() bool {
-	return t.shiftDown
-}
-
-func isLetter(s string) bool {
-	if len(s) != 1 {
-		return false
-	}
-	c := s[0]
-	return unicode.IsLetter(rune(c))
+// SetKeyboardGroup locks the X keyboard group, which selects one of the
+// configured keyboard layouts.
+func (t *Xdo) SetKeyboardGroup(group int) {
+	C.XkbLockGroup(t.xdo.xdpy, C.XkbUseCoreKbd, C.uint(group))
+	C.XFlush(t.xdo.xdpy)
 }